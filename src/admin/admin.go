@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// command is a subcommand of `equisplit admin`.
+type command struct {
+	usage string
+	run   func(cli *CLI, args []string) error
+}
+
+// commands contains all subcommands of `equisplit admin` by name.
+var commands = map[string]command{
+	"generate-key": {
+		usage: "generate-key --alg EdDSA|RS256|HS256 --out file",
+		run:   (*CLI).generateKey,
+	},
+	"rebuild-balances": {
+		usage: "rebuild-balances [--group id] [--dry-run]",
+		run:   (*CLI).rebuildBalances,
+	},
+}
+
+// CLI runs administrative commands against the database, e.g. `equisplit admin rebuild-balances`.
+type CLI struct {
+	db  *gorm.DB
+	log zerolog.Logger
+	out io.Writer
+}
+
+// NewCLI will return new instance of CLI which writes the output of commands to out.
+func NewCLI(db *gorm.DB, log zerolog.Logger, out io.Writer) *CLI {
+	return &CLI{
+		db:  db,
+		log: log,
+		out: out,
+	}
+}
+
+// Run will run the command specified in args, which excludes `equisplit admin`.
+func (cli *CLI) Run(args []string) error {
+	if len(args) == 0 {
+		cli.usage()
+		return errors.New("command must be specified")
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		cli.usage()
+		return fmt.Errorf("unknown command %s", args[0])
+	}
+
+	return cmd.run(cli, args[1:])
+}
+
+// usage will print all available commands.
+func (cli *CLI) usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	usages := make([]string, len(names))
+	for index, name := range names {
+		usages[index] = "  equisplit admin " + commands[name].usage
+	}
+
+	fmt.Fprintf(cli.out, "Usage:\n%s\n", strings.Join(usages, "\n"))
+}
@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// generateKey will create a new signing key for the keyring with the algorithm specified with --alg
+// and write it to the file specified with --out. Existing file is never overwritten.
+func (cli *CLI) generateKey(args []string) error {
+	flags := flag.NewFlagSet("generate-key", flag.ContinueOnError)
+	flags.SetOutput(cli.out)
+
+	algorithm := flags.String("alg", "EdDSA", "algorithm of the key, EdDSA, RS256 or HS256")
+	out := flags.String("out", "", "file to write the key to")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return errors.New("--out must be specified")
+	}
+
+	var content []byte
+
+	switch *algorithm {
+	case "EdDSA":
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		content, err = encodePrivateKey(private)
+		if err != nil {
+			return err
+		}
+
+	case "RS256":
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return err
+		}
+		content, err = encodePrivateKey(private)
+		if err != nil {
+			return err
+		}
+
+	case "HS256":
+		secret := make([]byte, 64)
+		_, err = rand.Read(secret)
+		if err != nil {
+			return err
+		}
+		content = []byte(base64.RawURLEncoding.EncodeToString(secret) + "\n")
+
+	default:
+		return fmt.Errorf("unsupported algorithm %s", *algorithm)
+	}
+
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(content)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.out, "%s key written to %s, add it to the keyring file with a kid and activeFrom\n", *algorithm, *out)
+	return nil
+}
+
+// encodePrivateKey will encode specified private key as PEM encoded PKCS#8.
+func encodePrivateKey(private interface{}) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/balance"
+)
+
+// rebuildBalances will recompute balances and total spent of all groups, or of the group specified
+// with --group, print the mismatches as a diff and fix them unless --dry-run is specified.
+func (cli *CLI) rebuildBalances(args []string) error {
+	flags := flag.NewFlagSet("rebuild-balances", flag.ContinueOnError)
+	flags.SetOutput(cli.out)
+
+	group := flags.String("group", "", "id of the group to rebuild, all groups are rebuilt if not specified")
+	dryRun := flags.Bool("dry-run", false, "only report the mismatches without fixing them")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var groupId *uuid.UUID
+	if *group != "" {
+		id, err := uuid.Parse(*group)
+		if err != nil {
+			return fmt.Errorf("invalid group id %s", *group)
+		}
+		groupId = &id
+	}
+
+	mismatches, err := balance.NewEngine(cli.db).Reconcile(groupId, !*dryRun)
+	for _, mismatch := range mismatches {
+		fmt.Fprintln(cli.out, mismatch.String())
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(mismatches) == 0:
+		fmt.Fprintln(cli.out, "all balances are correct")
+	case *dryRun:
+		fmt.Fprintf(cli.out, "%d mismatches found, run without --dry-run to fix them\n", len(mismatches))
+	default:
+		fmt.Fprintf(cli.out, "%d mismatches fixed\n", len(mismatches))
+	}
+
+	return nil
+}
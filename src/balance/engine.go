@@ -0,0 +1,228 @@
+package balance
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Engine keeps the incoming and outgoing amounts of every member in user_groups in sync with the
+// outstanding amount of pending transactions of their group. Incoming amount of a member is the
+// amount other members owe them and outgoing amount is the amount they owe others, both in base
+// currency of the group. Outstanding amount of a transaction is its base amount minus settlements.
+// It also keeps total spent of the group in sync with its expenses and transactions.
+type Engine interface {
+	Refresh(uow *db.UnitOfWork, groupId uuid.UUID, userIds ...uuid.UUID) error
+	Reconcile(groupId *uuid.UUID, fix bool) ([]Mismatch, error)
+}
+
+type engine struct {
+	db *gorm.DB
+}
+
+// NewEngine will return new instance of balance Engine.
+func NewEngine(db *gorm.DB) Engine {
+	return &engine{
+		db: db,
+	}
+}
+
+// memberAmount is the sum of pending transactions of a member.
+type memberAmount struct {
+	UserId uuid.UUID
+	Amount int64
+}
+
+// Refresh will recompute the amounts of specified members of the group, or of all members if none
+// are specified, and total spent of the group. It must be called in the same unit of work which
+// modified the transactions.
+//
+// The group row and then user_groups rows are locked with SELECT ... FOR UPDATE before the sums are
+// read, so concurrent requests updating the same group are serialized and the later one always sees
+// the transactions committed by the earlier one. Rows are locked in order of user id to avoid deadlocks.
+func (e *engine) Refresh(uow *db.UnitOfWork, groupId uuid.UUID, userIds ...uuid.UUID) error {
+	group, userGroups, err := e.lockGroup(uow, groupId, uniqueIds(userIds))
+	if err != nil {
+		return err
+	}
+
+	totalSpent, err := e.sumTotalSpent(uow, group)
+	if err != nil {
+		return err
+	}
+
+	err = e.updateTotalSpent(uow, group, totalSpent)
+	if err != nil {
+		return err
+	}
+
+	incoming, outgoing, err := e.sumMemberAmounts(uow, group, userGroups)
+	if err != nil {
+		return err
+	}
+
+	for _, userGroup := range userGroups {
+		err = e.updateMember(uow, userGroup, incoming[userGroup.UserId], outgoing[userGroup.UserId])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockGroup will lock and fetch the group and specified members of the group, or all members if
+// none are specified.
+func (e *engine) lockGroup(uow *db.UnitOfWork, groupId uuid.UUID,
+	userIds []uuid.UUID) (models.Group, []models.UserGroup, error) {
+
+	group := models.Group{}
+
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("groups.id = ?", groupId).First(&group).Error
+	if err != nil {
+		return group, nil, err
+	}
+
+	userGroups := []models.UserGroup{}
+
+	queryDB := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_groups.group_id = ?", groupId)
+	if len(userIds) > 0 {
+		queryDB = queryDB.Where("user_groups.user_id IN (?)", userIds)
+	}
+
+	err = queryDB.Order("user_groups.user_id").Find(&userGroups).Error
+	if err != nil {
+		return group, nil, err
+	}
+
+	return group, userGroups, nil
+}
+
+// sumMemberAmounts will return incoming and outgoing amounts of specified members.
+func (e *engine) sumMemberAmounts(uow *db.UnitOfWork, group models.Group,
+	userGroups []models.UserGroup) (map[uuid.UUID]models.Money, map[uuid.UUID]models.Money, error) {
+
+	incoming := make(map[uuid.UUID]models.Money, len(userGroups))
+	outgoing := make(map[uuid.UUID]models.Money, len(userGroups))
+
+	if len(userGroups) == 0 {
+		return incoming, outgoing, nil
+	}
+
+	memberIds := make([]uuid.UUID, len(userGroups))
+	for index := range userGroups {
+		memberIds[index] = userGroups[index].UserId
+		incoming[memberIds[index]] = models.NewMoney(0, group.BaseCurrency)
+		outgoing[memberIds[index]] = models.NewMoney(0, group.BaseCurrency)
+	}
+
+	amounts, err := e.sumAmounts(uow, group.Id, "payer_id", memberIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for userId, amount := range amounts {
+		incoming[userId] = models.NewMoney(amount, group.BaseCurrency)
+	}
+
+	amounts, err = e.sumAmounts(uow, group.Id, "payee_id", memberIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for userId, amount := range amounts {
+		outgoing[userId] = models.NewMoney(amount, group.BaseCurrency)
+	}
+
+	return incoming, outgoing, nil
+}
+
+// sumAmounts will sum outstanding amount of pending transactions of the group by specified column.
+func (e *engine) sumAmounts(uow *db.UnitOfWork, groupId uuid.UUID, column string,
+	userIds []uuid.UUID) (map[uuid.UUID]int64, error) {
+
+	amounts := []memberAmount{}
+
+	err := uow.DB.Model(&models.GroupTransaction{}).
+		Select(column+" AS user_id, SUM(base_amount_minor - paid_amount_minor) AS amount").
+		Where("group_transactions.group_id = ? AND group_transactions."+column+" IN (?)", groupId, userIds).
+		Where("group_transactions.is_paid = ? AND group_transactions.is_adjusted = ?", false, false).
+		Group(column).Scan(&amounts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uuid.UUID]int64, len(amounts))
+	for _, a := range amounts {
+		result[a.UserId] = a.Amount
+	}
+
+	return result, nil
+}
+
+// sumTotalSpent will sum base amount of all expenses of the group and of transactions which were
+// not created for an expense. Transfers of a settlement plan only move money and are not counted.
+func (e *engine) sumTotalSpent(uow *db.UnitOfWork, group models.Group) (models.Money, error) {
+	var expenses, transactions int64
+
+	err := uow.DB.Model(&models.Expense{}).Select("COALESCE(SUM(base_amount_minor), 0)").
+		Where("expenses.group_id = ?", group.Id).Scan(&expenses).Error
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	err = uow.DB.Model(&models.GroupTransaction{}).Select("COALESCE(SUM(base_amount_minor), 0)").
+		Where("group_transactions.group_id = ? AND group_transactions.expense_id IS NULL", group.Id).
+		Where("group_transactions.is_settlement = ?", false).Scan(&transactions).Error
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	return models.NewMoney(expenses+transactions, group.BaseCurrency), nil
+}
+
+// updateTotalSpent will set total spent of the group.
+func (e *engine) updateTotalSpent(uow *db.UnitOfWork, group models.Group, totalSpent models.Money) error {
+	return uow.DB.Model(&models.Group{}).Where("groups.id = ?", group.Id).
+		Updates(map[string]interface{}{
+			"total_spent_minor":    totalSpent.Minor,
+			"total_spent_currency": totalSpent.Currency,
+		}).Error
+}
+
+// updateMember will set incoming and outgoing amount of the member.
+func (e *engine) updateMember(uow *db.UnitOfWork, userGroup models.UserGroup, incoming, outgoing models.Money) error {
+	return uow.DB.Model(&models.UserGroup{}).Where("user_groups.id = ?", userGroup.Id).
+		Updates(map[string]interface{}{
+			"incoming_amount_minor":    incoming.Minor,
+			"incoming_amount_currency": incoming.Currency,
+			"outgoing_amount_minor":    outgoing.Minor,
+			"outgoing_amount_currency": outgoing.Currency,
+		}).Error
+}
+
+// uniqueIds will remove duplicate and nil ids and sort them.
+func uniqueIds(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	result := []uuid.UUID{}
+
+	for _, id := range ids {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+
+	return result
+}
@@ -0,0 +1,27 @@
+package balance
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// StartReconcileJob will reconcile balances of all groups every interval in the background and
+// log the mismatches found. Mismatches are fixed only if fix is true, otherwise they can be fixed
+// using `equisplit admin rebuild-balances`.
+func StartReconcileJob(engine Engine, interval time.Duration, fix bool, log zerolog.Logger) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			mismatches, err := engine.Reconcile(nil, fix)
+			for _, mismatch := range mismatches {
+				log.Warn().Bool("fixed", fix).Msg("Balance mismatch " + mismatch.String())
+			}
+			if err != nil {
+				log.Error().Err(err).Msg("Error reconciling balances")
+			}
+		}
+	}()
+}
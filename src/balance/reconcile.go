@@ -0,0 +1,153 @@
+package balance
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+)
+
+// Mismatch is an aggregate whose stored value differs from the value computed from transactions.
+// UserId is nil for aggregates of the group.
+type Mismatch struct {
+	GroupId  uuid.UUID    `json:"groupId"`
+	UserId   *uuid.UUID   `json:"userId"`
+	Field    string       `json:"field"`
+	Stored   models.Money `json:"stored"`
+	Computed models.Money `json:"computed"`
+}
+
+// String will return the mismatch as a line, e.g.
+// "group <id> user <id> incoming_amount: stored -10.00 INR, computed 12.00 INR".
+func (m Mismatch) String() string {
+	owner := "group " + m.GroupId.String()
+	if m.UserId != nil {
+		owner += " user " + m.UserId.String()
+	}
+
+	return fmt.Sprintf("%s %s: stored %s %s, computed %s %s", owner, m.Field,
+		m.Stored.String(), m.Stored.Currency, m.Computed.String(), m.Computed.Currency)
+}
+
+// Reconcile will recompute all aggregates of specified group, or of all groups if groupId is nil,
+// from its expenses and transactions and return the ones which differ from the stored values.
+// Mismatches are fixed only if fix is true. Every group is reconciled in its own unit of work.
+func (e *engine) Reconcile(groupId *uuid.UUID, fix bool) ([]Mismatch, error) {
+	groupIds := []uuid.UUID{}
+
+	queryDB := e.db.Model(&models.Group{})
+	if groupId != nil {
+		queryDB = queryDB.Where("groups.id = ?", *groupId)
+	}
+
+	err := queryDB.Order("groups.id").Pluck("groups.id", &groupIds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if groupId != nil && len(groupIds) == 0 {
+		return nil, fmt.Errorf("group %s not found", groupId.String())
+	}
+
+	mismatches := []Mismatch{}
+
+	for _, id := range groupIds {
+		groupMismatches, err := e.reconcileGroup(id, fix)
+		if err != nil {
+			return mismatches, err
+		}
+		mismatches = append(mismatches, groupMismatches...)
+	}
+
+	return mismatches, nil
+}
+
+// reconcileGroup will compare and optionally fix aggregates of specified group.
+func (e *engine) reconcileGroup(groupId uuid.UUID, fix bool) ([]Mismatch, error) {
+	uow := db.NewUnitOfWork(e.db)
+	defer uow.RollBack()
+
+	group, userGroups, err := e.lockGroup(uow, groupId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := []Mismatch{}
+
+	totalSpent, err := e.sumTotalSpent(uow, group)
+	if err != nil {
+		return nil, err
+	}
+
+	// stored amounts are compared in base currency of the group, as members could be added with the
+	// default currency instead of the base currency.
+	storedTotalSpent := models.NewMoney(group.TotalSpent.Minor, group.BaseCurrency)
+
+	if storedTotalSpent.Minor != totalSpent.Minor {
+		mismatches = append(mismatches, Mismatch{
+			GroupId:  groupId,
+			Field:    "total_spent",
+			Stored:   storedTotalSpent,
+			Computed: totalSpent,
+		})
+
+		if fix {
+			err = e.updateTotalSpent(uow, group, totalSpent)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	incoming, outgoing, err := e.sumMemberAmounts(uow, group, userGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range userGroups {
+		userGroup := userGroups[index]
+		memberMismatches := []Mismatch{}
+
+		storedIncoming := models.NewMoney(userGroup.IncomingAmount.Minor, group.BaseCurrency)
+		storedOutgoing := models.NewMoney(userGroup.OutgoingAmount.Minor, group.BaseCurrency)
+
+		if storedIncoming.Minor != incoming[userGroup.UserId].Minor {
+			memberMismatches = append(memberMismatches, Mismatch{
+				GroupId:  groupId,
+				UserId:   &userGroup.UserId,
+				Field:    "incoming_amount",
+				Stored:   storedIncoming,
+				Computed: incoming[userGroup.UserId],
+			})
+		}
+
+		if storedOutgoing.Minor != outgoing[userGroup.UserId].Minor {
+			memberMismatches = append(memberMismatches, Mismatch{
+				GroupId:  groupId,
+				UserId:   &userGroup.UserId,
+				Field:    "outgoing_amount",
+				Stored:   storedOutgoing,
+				Computed: outgoing[userGroup.UserId],
+			})
+		}
+
+		if len(memberMismatches) == 0 {
+			continue
+		}
+		mismatches = append(mismatches, memberMismatches...)
+
+		if fix {
+			err = e.updateMember(uow, userGroup, incoming[userGroup.UserId], outgoing[userGroup.UserId])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if fix {
+		uow.Commit()
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/mail"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTooManyLoginAttempts is returned when a login is refused because of previous failed logins of
+// the account or the IP.
+var ErrTooManyLoginAttempts = errors.New("too many failed login attempts, please try again later")
+
+// errInvalidCredentials is returned for both unknown emails and wrong passwords, so that the login
+// cannot be used to find out who is registered.
+var errInvalidCredentials = errors.New("email or password did not match")
+
+var (
+	// accountBackoff is the policy for failed logins of an account. It is locked for an hour after
+	// 10 failures, and the user is emailed a link to unlock it.
+	accountBackoff = security.BackoffPolicy{
+		Threshold:    3,
+		Base:         2 * time.Second,
+		Max:          5 * time.Minute,
+		LockoutAfter: 10,
+		Lockout:      time.Hour,
+		Reset:        24 * time.Hour,
+	}
+	// ipBackoff is the policy for failed logins from an IP, whichever accounts they are for. It allows
+	// more failures than accountBackoff as many users can share an IP.
+	ipBackoff = security.BackoffPolicy{
+		Threshold:    20,
+		Base:         time.Second,
+		Max:          5 * time.Minute,
+		LockoutAfter: 100,
+		Lockout:      time.Hour,
+		Reset:        time.Hour,
+	}
+)
+
+type AccountLockController interface {
+	Unlock(token, ipAddress string) error
+	UnlockUser(userId uuid.UUID, ipAddress string) error
+}
+
+type accountLockController struct {
+	db *gorm.DB
+}
+
+// NewAccountLockController will return new instance of AccountLockController.
+func NewAccountLockController(db *gorm.DB) AccountLockController {
+	return &accountLockController{
+		db: db,
+	}
+}
+
+// Unlock will unlock the account of the user whose unlock token is specified, if the token is not used
+// or expired.
+func (a *accountLockController) Unlock(token, ipAddress string) error {
+	if token == "" {
+		return errors.New("token must be specified")
+	}
+
+	uow := db.NewUnitOfWork(a.db)
+	defer uow.RollBack()
+
+	unlockToken := models.AccountUnlockToken{}
+
+	// token is locked so that it cannot be used twice.
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("account_unlock_tokens.token_hash = ?", security.HashToken(token)).First(&unlockToken).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("invalid or expired unlock token")
+		}
+		return err
+	}
+
+	if !unlockToken.IsValid() {
+		return errors.New("invalid or expired unlock token")
+	}
+
+	err = unlockAccount(uow, unlockToken.UserId, ipAddress, "unlocked with emailed link")
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// UnlockUser will unlock the account of specified user on behalf of an admin.
+func (a *accountLockController) UnlockUser(userId uuid.UUID, ipAddress string) error {
+	uow := db.NewUnitOfWork(a.db)
+	defer uow.RollBack()
+
+	err := uow.DB.Where("users.id = ?", userId).First(&models.User{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	err = unlockAccount(uow, userId, ipAddress, "unlocked by admin")
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// unlockAccount will clear failed logins of specified user, invalidate their unlock tokens and record
+// the unlock.
+func unlockAccount(uow *db.UnitOfWork, userId uuid.UUID, ipAddress, detail string) error {
+	err := uow.DB.Model(&models.User{}).Where("users.id = ?", userId).
+		Updates(map[string]interface{}{
+			"FailedLoginCount":  0,
+			"LastFailedLoginAt": nil,
+			"LockedUntil":       nil,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.AccountUnlockToken{}).
+		Where("account_unlock_tokens.user_id = ? AND account_unlock_tokens.used_at IS NULL", userId).
+		Update("UsedAt", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	return uow.DB.Create(&models.SecurityEvent{
+		UserId:    &userId,
+		Type:      models.SecurityEventAccountUnlocked,
+		IPAddress: ipAddress,
+		Detail:    detail,
+	}).Error
+}
+
+// loginGuard tracks failed logins of accounts and IPs. Failures of accounts are stored with the user,
+// failures of IPs and of emails which are not registered are counted in memory.
+type loginGuard struct {
+	mailer mail.Sender
+	ips    *security.Throttle
+	// emails throttles unregistered emails like accounts, so that backoff does not tell if an email
+	// is registered.
+	emails *security.Throttle
+}
+
+// newLoginGuard will return new instance of loginGuard.
+func newLoginGuard(mailer mail.Sender) *loginGuard {
+	return &loginGuard{
+		mailer: mailer,
+		ips:    security.NewThrottle(ipBackoff),
+		emails: security.NewThrottle(accountBackoff),
+	}
+}
+
+// allowIP will check if a login can be attempted from specified IP.
+func (g *loginGuard) allowIP(ipAddress string) bool {
+	return g.ips.Allow(ipAddress)
+}
+
+// allowEmail will check if a login can be attempted for specified email which is not registered.
+func (g *loginGuard) allowEmail(email string) bool {
+	return g.emails.Allow(strings.ToLower(email))
+}
+
+// failEmail will record a failed login for specified email which is not registered.
+func (g *loginGuard) failEmail(uow *db.UnitOfWork, email, ipAddress string) error {
+	g.emails.Fail(strings.ToLower(email))
+	return g.failIP(uow, ipAddress)
+}
+
+// failIP will record a failed login from specified IP, and record an event if it locked the IP.
+func (g *loginGuard) failIP(uow *db.UnitOfWork, ipAddress string) error {
+	if !g.ips.Fail(ipAddress) {
+		return nil
+	}
+
+	return uow.DB.Create(&models.SecurityEvent{
+		Type:      models.SecurityEventIPLocked,
+		IPAddress: ipAddress,
+		Detail:    "too many failed logins",
+	}).Error
+}
+
+// fail will record a failed login of specified user from the IP. User has to wait before the next
+// attempt as specified by accountBackoff, and when the account is locked, the lockout is recorded and
+// the user is emailed a link to unlock it. User must be locked by the caller.
+func (g *loginGuard) fail(uow *db.UnitOfWork, user *models.User, ipAddress string) error {
+	err := g.failIP(uow, ipAddress)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if user.LastFailedLoginAt == nil || now.Sub(*user.LastFailedLoginAt) >= accountBackoff.Reset {
+		user.FailedLoginCount = 0
+	}
+
+	user.FailedLoginCount++
+	user.LastFailedLoginAt = &now
+	user.LockedUntil = nil
+
+	delay, locked := accountBackoff.Delay(user.FailedLoginCount)
+	if delay > 0 {
+		lockedUntil := now.Add(delay)
+		user.LockedUntil = &lockedUntil
+	}
+
+	err = uow.DB.Model(&models.User{}).Where("users.id = ?", user.Id).
+		Updates(map[string]interface{}{
+			"FailedLoginCount":  user.FailedLoginCount,
+			"LastFailedLoginAt": user.LastFailedLoginAt,
+			"LockedUntil":       user.LockedUntil,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	if !locked {
+		return nil
+	}
+
+	err = uow.DB.Create(&models.SecurityEvent{
+		UserId:    &user.Id,
+		Type:      models.SecurityEventAccountLocked,
+		IPAddress: ipAddress,
+		Detail:    "locked after too many failed logins",
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return g.sendUnlock(uow, user)
+}
+
+// succeed will clear failed logins of specified user after they have logged in.
+func (g *loginGuard) succeed(uow *db.UnitOfWork, user *models.User) error {
+	if user.FailedLoginCount == 0 && user.LockedUntil == nil {
+		return nil
+	}
+
+	return uow.DB.Model(&models.User{}).Where("users.id = ?", user.Id).
+		Updates(map[string]interface{}{
+			"FailedLoginCount":  0,
+			"LastFailedLoginAt": nil,
+			"LockedUntil":       nil,
+		}).Error
+}
+
+// sendUnlock will email a link to unlock their account to specified user. Previous unlock tokens of
+// the user can no longer be used.
+func (g *loginGuard) sendUnlock(uow *db.UnitOfWork, user *models.User) error {
+	err := uow.DB.Model(&models.AccountUnlockToken{}).
+		Where("account_unlock_tokens.user_id = ? AND account_unlock_tokens.used_at IS NULL", user.Id).
+		Update("UsedAt", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := security.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Create(&models.AccountUnlockToken{
+		UserId:    user.Id,
+		TokenHash: hash,
+		ExpiresOn: time.Now().Add(models.AccountUnlockExpiry),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Name":    user.Name,
+		"Token":   token,
+		"Minutes": int(accountBackoff.Lockout.Minutes()),
+		"Hours":   int(models.AccountUnlockExpiry.Hours()),
+	}
+
+	// link is sent only if the url of the app is known, otherwise the token has to be entered.
+	if appURL := os.Getenv("APP_URL"); appURL != "" {
+		data["Link"] = appURL + "/unlock-account?token=" + token
+	}
+
+	message, err := mail.Render(user.Email, "account-locked", data)
+	if err != nil {
+		return err
+	}
+
+	return g.mailer.Send(message)
+}
@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/balance"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/split"
+	"github.com/shaileshhb/equisplit/src/util"
+	"gorm.io/gorm"
+)
+
+// ExpenseController will contain all methods to be implemented by expense controller
+type ExpenseController interface {
+	Add(expense *models.Expense) error
+	Update(expense *models.Expense, userId uuid.UUID) error
+	Delete(userId, expenseId uuid.UUID) error
+	GetExpense(expense *models.ExpenseDTO) error
+	GetGroupExpenses(expenses *[]models.ExpenseDTO, groupId uuid.UUID, totalCount *int64, parser *util.Parser) error
+}
+
+type expenseController struct {
+	db             *gorm.DB
+	transactionCon GroupTransactionController
+	fxCon          FxRateController
+	balance        balance.Engine
+}
+
+// NewExpenseController will return new instance of ExpenseController.
+func NewExpenseController(db *gorm.DB) ExpenseController {
+	return &expenseController{
+		db:             db,
+		transactionCon: NewGroupTransactionController(db),
+		fxCon:          NewFxRateController(db),
+		balance:        balance.NewEngine(db),
+	}
+}
+
+// Add will add new expense and create transactions for every participant who owes the payer.
+func (e *expenseController) Add(expense *models.Expense) error {
+	err := e.validateExpense(expense)
+	if err != nil {
+		return err
+	}
+
+	err = checkPermission(e.db, expense.CreatedBy, expense.GroupId, models.PermissionAddExpense)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(e.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Create(expense).Error
+	if err != nil {
+		return err
+	}
+
+	err = e.addTransactions(uow, expense)
+	if err != nil {
+		return err
+	}
+
+	// updates total spent of the group.
+	err = e.balance.Refresh(uow, expense.GroupId, expense.PayerId)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Update will update specified expense and regenerate its transactions. Expenses of other members
+// can be updated only by roles which are allowed to delete them.
+func (e *expenseController) Update(expense *models.Expense, userId uuid.UUID) error {
+	tempExpense := models.Expense{}
+
+	err := e.db.Where("expenses.id = ?", expense.Id).First(&tempExpense).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("expense not found")
+		}
+		return err
+	}
+
+	if tempExpense.CreatedBy != userId {
+		err = checkPermission(e.db, userId, tempExpense.GroupId, models.PermissionDeleteOthersExpenses)
+		if err != nil {
+			return err
+		}
+	}
+
+	expense.GroupId = tempExpense.GroupId
+	expense.CreatedBy = tempExpense.CreatedBy
+	expense.CreatedAt = tempExpense.CreatedAt
+
+	err = expense.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = e.validateExpense(expense)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(e.db)
+	defer uow.RollBack()
+
+	err = e.transactionCon.DeleteExpenseTransactions(uow, expense.Id)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Where("expense_splits.expense_id = ?", expense.Id).Delete(&models.ExpenseSplit{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Omit("Splits").Save(expense).Error
+	if err != nil {
+		return err
+	}
+
+	for index := range expense.Splits {
+		expense.Splits[index].ExpenseId = expense.Id
+	}
+
+	err = uow.DB.Create(&expense.Splits).Error
+	if err != nil {
+		return err
+	}
+
+	err = e.addTransactions(uow, expense)
+	if err != nil {
+		return err
+	}
+
+	// updates total spent of the group.
+	err = e.balance.Refresh(uow, expense.GroupId, expense.PayerId)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Delete will delete specified expense along with all of its transactions. Expenses of other members
+// can be deleted only by roles which have the permission.
+func (e *expenseController) Delete(userId, expenseId uuid.UUID) error {
+	expense := models.Expense{}
+
+	err := e.db.Where("expenses.id = ?", expenseId).First(&expense).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("expense not found")
+		}
+		return err
+	}
+
+	if expense.CreatedBy != userId {
+		err = checkPermission(e.db, userId, expense.GroupId, models.PermissionDeleteOthersExpenses)
+		if err != nil {
+			return err
+		}
+	}
+
+	uow := db.NewUnitOfWork(e.db)
+	defer uow.RollBack()
+
+	err = e.transactionCon.DeleteExpenseTransactions(uow, expenseId)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Where("expense_splits.expense_id = ?", expenseId).Delete(&models.ExpenseSplit{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Delete(&models.Expense{}, expenseId).Error
+	if err != nil {
+		return err
+	}
+
+	// updates total spent of the group.
+	err = e.balance.Refresh(uow, expense.GroupId, expense.PayerId)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// GetExpense will fetch specified expense with its splits and transactions.
+func (e *expenseController) GetExpense(expense *models.ExpenseDTO) error {
+	uow := db.NewUnitOfWork(e.db)
+	defer uow.RollBack()
+
+	err := uow.DB.Preload("Payer").Preload("Splits").Preload("Transactions").
+		Where("expenses.id = ?", expense.Id).First(expense).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("expense not found")
+		}
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// GetGroupExpenses will fetch all expenses of specified group.
+func (e *expenseController) GetGroupExpenses(expenses *[]models.ExpenseDTO, groupId uuid.UUID,
+	totalCount *int64, parser *util.Parser) error {
+
+	err := e.doesGroupExist(groupId)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(e.db)
+	defer uow.RollBack()
+
+	whereDB := uow.DB.Model(&models.Expense{}).Where("expenses.group_id = ?", groupId)
+
+	err = whereDB.Count(totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	limit, offset := parser.ParseLimitAndOffset()
+
+	err = whereDB.Limit(limit).Offset(offset).Preload("Payer").Preload("Splits").Preload("Transactions").
+		Order("expenses.date DESC").Find(expenses).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// validateExpense will check payer and participants, resolve the amount of every split and
+// convert the amount into base currency of the group.
+func (e *expenseController) validateExpense(expense *models.Expense) error {
+	group := models.Group{}
+
+	err := e.db.Where("groups.id = ?", expense.GroupId).First(&group).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("group not found")
+		}
+		return err
+	}
+
+	err = e.doesUserExistInGroup(expense.PayerId, expense.GroupId)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range expense.Splits {
+		err = e.doesUserExistInGroup(s.UserId, expense.GroupId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if expense.Date.IsZero() {
+		expense.Date = time.Now()
+	}
+
+	err = expense.Amount.SetDefaultCurrency(group.BaseCurrency)
+	if err != nil {
+		return err
+	}
+
+	err = split.Apply(expense)
+	if err != nil {
+		return err
+	}
+
+	expense.BaseAmount, expense.FxRate, err = e.fxCon.Convert(expense.Amount, group.BaseCurrency, expense.Date)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addTransactions will create a transaction for every participant other than the payer.
+// Base amount of the expense is allocated in the same proportion as the splits, so that base
+// amounts of the transactions add up exactly to the base amount of the expense.
+func (e *expenseController) addTransactions(uow *db.UnitOfWork, expense *models.Expense) error {
+	weights := make([]int64, len(expense.Splits))
+	for index, s := range expense.Splits {
+		weights[index] = s.Amount.Minor
+	}
+
+	baseAmounts, err := expense.BaseAmount.Allocate(weights)
+	if err != nil {
+		return err
+	}
+
+	for index, s := range expense.Splits {
+		if s.UserId == expense.PayerId || s.Amount.IsZero() {
+			continue
+		}
+
+		transaction := models.GroupTransaction{
+			PayerId:     expense.PayerId,
+			PayeeId:     s.UserId,
+			GroupId:     expense.GroupId,
+			Amount:      s.Amount,
+			Date:        &expense.Date,
+			Description: expense.Description,
+			ExpenseId:   &expense.Id,
+		}
+
+		err = e.transactionCon.AddExpenseTransaction(uow, &transaction, baseAmounts[index], expense.FxRate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doesGroupExist will check if specified group exist or not.
+func (e *expenseController) doesGroupExist(groupId uuid.UUID) error {
+	err := e.db.Where("groups.id = ?", groupId).First(&models.Group{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("group not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// doesUserExistInGroup will check if specified user exist in group or not.
+func (e *expenseController) doesUserExistInGroup(userId, groupId uuid.UUID) error {
+	err := e.db.Where("user_groups.user_id = ? AND user_groups.group_id = ?", userId, groupId).
+		First(&models.UserGroup{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found in this group")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FxRateController will contain all methods to be implemented by fx rate controller
+type FxRateController interface {
+	ImportRates(data []byte, format string, totalCount *int) error
+	LoadFile(path string, totalCount *int) error
+	GetRates(rates *[]models.FxRate, parser *util.Parser) error
+	Convert(amount models.Money, currency string, date time.Time) (models.Money, float64, error)
+}
+
+type fxRateController struct {
+	db *gorm.DB
+}
+
+// NewFxRateController will return new instance of FxRateController.
+func NewFxRateController(db *gorm.DB) FxRateController {
+	return &fxRateController{
+		db: db,
+	}
+}
+
+// ImportRates will parse rates from CSV or JSON data and add them. Existing rate for the same
+// currencies and date is replaced. CSV must have the header date,from,to,rate.
+func (f *fxRateController) ImportRates(data []byte, format string, totalCount *int) error {
+	var rates []models.FxRateDTO
+	var err error
+
+	switch strings.ToLower(format) {
+	case "csv":
+		rates, err = parseCSVRates(data)
+	case "json":
+		err = json.Unmarshal(data, &rates)
+	default:
+		return errors.New("rates must be specified in csv or json format")
+	}
+	if err != nil {
+		return err
+	}
+
+	fxRates := make([]models.FxRate, 0, len(rates))
+	for index, r := range rates {
+		date, err := time.Parse(time.DateOnly, strings.TrimSpace(r.Date))
+		if err != nil {
+			return fmt.Errorf("invalid date specified for rate %d", index+1)
+		}
+
+		fxRate := models.FxRate{
+			FromCurrency: r.From,
+			ToCurrency:   r.To,
+			Date:         date,
+			Rate:         r.Rate,
+		}
+
+		err = fxRate.Validate()
+		if err != nil {
+			return fmt.Errorf("rate %d: %s", index+1, err.Error())
+		}
+
+		fxRates = append(fxRates, fxRate)
+	}
+
+	if len(fxRates) == 0 {
+		return errors.New("no rates specified")
+	}
+
+	uow := db.NewUnitOfWork(f.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_currency"}, {Name: "to_currency"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at", "deleted_at"}),
+	}).CreateInBatches(&fxRates, 500).Error
+	if err != nil {
+		return err
+	}
+
+	*totalCount = len(fxRates)
+
+	uow.Commit()
+	return nil
+}
+
+// LoadFile will import rates from specified .csv or .json file.
+func (f *fxRateController) LoadFile(path string, totalCount *int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return f.ImportRates(data, strings.TrimPrefix(filepath.Ext(path), "."), totalCount)
+}
+
+// GetRates will fetch all rates.
+func (f *fxRateController) GetRates(rates *[]models.FxRate, parser *util.Parser) error {
+	uow := db.NewUnitOfWork(f.db)
+	defer uow.RollBack()
+
+	queryDB := uow.DB
+
+	if len(parser.GetQuery("from")) > 0 {
+		queryDB = queryDB.Where("fx_rates.from_currency = ?", strings.ToUpper(parser.GetQuery("from")))
+	}
+
+	if len(parser.GetQuery("to")) > 0 {
+		queryDB = queryDB.Where("fx_rates.to_currency = ?", strings.ToUpper(parser.GetQuery("to")))
+	}
+
+	limit, offset := parser.ParseLimitAndOffset()
+
+	err := queryDB.Order("fx_rates.date DESC").Limit(limit).Offset(offset).Find(rates).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Convert will convert amount into specified currency using the latest rate on or before date.
+// If only the inverse rate is available it is used instead. It returns converted amount and rate used.
+func (f *fxRateController) Convert(amount models.Money, currency string, date time.Time) (models.Money, float64, error) {
+	if amount.SameCurrency(models.NewMoney(0, currency)) {
+		return models.NewMoney(amount.Minor, currency), 1, nil
+	}
+
+	rate, err := f.getRate(amount.Currency, currency, date)
+	if err != nil {
+		return models.Money{}, 0, err
+	}
+
+	converted, err := amount.Convert(rate, currency)
+	if err != nil {
+		return models.Money{}, 0, err
+	}
+
+	return converted, rate, nil
+}
+
+// getRate will fetch the latest rate on or before date for specified currencies.
+func (f *fxRateController) getRate(from, to string, date time.Time) (float64, error) {
+	fxRate := models.FxRate{}
+
+	err := f.db.Where("fx_rates.from_currency = ? AND fx_rates.to_currency = ? AND fx_rates.date <= ?",
+		from, to, date).Order("fx_rates.date DESC").First(&fxRate).Error
+	if err == nil {
+		return fxRate.Rate, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	err = f.db.Where("fx_rates.from_currency = ? AND fx_rates.to_currency = ? AND fx_rates.date <= ?",
+		to, from, date).Order("fx_rates.date DESC").First(&fxRate).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, fmt.Errorf("exchange rate from %s to %s not found for %s", from, to, date.Format(time.DateOnly))
+		}
+		return 0, err
+	}
+
+	return 1 / fxRate.Rate, nil
+}
+
+// parseCSVRates will parse rates from CSV data with the header date,from,to,rate.
+func parseCSVRates(data []byte) ([]models.FxRateDTO, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("csv must have the header date,from,to,rate")
+	}
+
+	columns := make(map[string]int, len(header))
+	for index, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = index
+	}
+
+	for _, column := range []string{"date", "from", "to", "rate"} {
+		if _, ok := columns[column]; !ok {
+			return nil, errors.New("csv must have the header date,from,to,rate")
+		}
+	}
+
+	rates := []models.FxRateDTO{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[columns["rate"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate specified on line %d", line)
+		}
+
+		rates = append(rates, models.FxRateDTO{
+			Date: record[columns["date"]],
+			From: record[columns["from"]],
+			To:   record[columns["to"]],
+			Rate: rate,
+		})
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GroupInviteLinkController interface {
+	Add(link *models.GroupInviteLink, token *string) error
+	GetActiveLinks(links *[]models.GroupInviteLinkDTO, userId, groupId uuid.UUID) error
+	Revoke(linkId, userId uuid.UUID) error
+	Join(token string, userId uuid.UUID) (uuid.UUID, error)
+}
+
+type groupInviteLinkController struct {
+	db *gorm.DB
+}
+
+// NewGroupInviteLinkController will return new instance of GroupInviteLinkController.
+func NewGroupInviteLinkController(db *gorm.DB) GroupInviteLinkController {
+	return &groupInviteLinkController{
+		db: db,
+	}
+}
+
+// Add will create new invite link for the group and return its token. Only roles which can invite
+// users can create a link.
+func (g *groupInviteLinkController) Add(link *models.GroupInviteLink, token *string) error {
+	err := checkPermission(g.db, link.CreatedBy, link.GroupId, models.PermissionInvite)
+	if err != nil {
+		return err
+	}
+
+	err = checkEmailVerified(g.db, link.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	link.UseCount = 0
+	link.RevokedAt = nil
+	link.RevokedBy = nil
+
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Create(link).Error
+	if err != nil {
+		return err
+	}
+
+	*token, err = security.GenerateInviteJwt(link.Id, link.GroupId, link.ExpiresOn)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// GetActiveLinks will fetch invite links of specified group which can still be used, with their
+// tokens and the users who joined through them. Only roles which can invite users can see the links,
+// as anyone with a token can join the group.
+func (g *groupInviteLinkController) GetActiveLinks(links *[]models.GroupInviteLinkDTO, userId, groupId uuid.UUID) error {
+	err := checkPermission(g.db, userId, groupId, models.PermissionInvite)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Where("group_invite_links.group_id = ? AND group_invite_links.revoked_at IS NULL", groupId).
+		Where("group_invite_links.expires_on > ?", time.Now()).
+		Where("(group_invite_links.max_uses IS NULL OR group_invite_links.use_count < group_invite_links.max_uses)").
+		Preload("CreatedByUser").Preload("Joins").Order("group_invite_links.created_at DESC").Find(links).Error
+	if err != nil {
+		return err
+	}
+
+	for index := range *links {
+		(*links)[index].Token, err = security.GenerateInviteJwt((*links)[index].Id, groupId, (*links)[index].ExpiresOn)
+		if err != nil {
+			return err
+		}
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Revoke will revoke specified invite link, so that nobody can join the group through it. A link can
+// be revoked by its creator or by roles which can remove members.
+func (g *groupInviteLinkController) Revoke(linkId, userId uuid.UUID) error {
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	link := models.GroupInviteLink{}
+
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("group_invite_links.id = ?", linkId).First(&link).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("invite link not found")
+		}
+		return err
+	}
+
+	if link.CreatedBy != userId {
+		err = checkPermission(uow.DB, userId, link.GroupId, models.PermissionRemoveMember)
+		if err != nil {
+			return err
+		}
+	}
+
+	if link.RevokedAt != nil {
+		return errors.New("invite link is already revoked")
+	}
+
+	err = uow.DB.Model(&models.GroupInviteLink{}).Where("group_invite_links.id = ?", linkId).
+		Updates(map[string]interface{}{
+			"RevokedBy": userId,
+			"RevokedAt": time.Now(),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Join will add the user to the group of the invite link specified by the token and return id of
+// the group. Link is locked so that concurrent joins cannot exceed its max uses.
+func (g *groupInviteLinkController) Join(token string, userId uuid.UUID) (uuid.UUID, error) {
+	linkId, err := security.ValidateInviteJwt(token)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid or expired invite link")
+	}
+
+	err = doesRegisteredUserExist(g.db, userId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	link := models.GroupInviteLink{}
+
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("group_invite_links.id = ?", linkId).First(&link).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return uuid.Nil, errors.New("invite link not found")
+		}
+		return uuid.Nil, err
+	}
+
+	if !link.IsActive() {
+		return uuid.Nil, errors.New("invite link is revoked, expired or has reached its max uses")
+	}
+
+	var totalCount int64
+
+	err = uow.DB.Model(&models.UserGroup{}).
+		Where("user_groups.user_id = ? AND user_groups.group_id = ?", userId, link.GroupId).
+		Count(&totalCount).Error
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if totalCount > 0 {
+		return uuid.Nil, errors.New("user already exists in specified group")
+	}
+
+	err = checkMemberLimit(uow, link.GroupId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = addMember(uow, userId, link.GroupId, models.RoleMember)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = uow.DB.Model(&models.GroupInviteLink{}).Where("group_invite_links.id = ?", link.Id).
+		Update("UseCount", gorm.Expr("use_count + 1")).Error
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = uow.DB.Create(&models.GroupInviteLinkJoin{
+		LinkId:  link.Id,
+		UserId:  userId,
+		GroupId: link.GroupId,
+	}).Error
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	uow.Commit()
+	return link.GroupId, nil
+}
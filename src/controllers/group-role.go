@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxGroupMembers is the maximum number of members a group can have.
+const maxGroupMembers = 10
+
+// checkMemberLimit will check if another member can be added to specified group. Group is locked so
+// that concurrent requests cannot add more members than the limit.
+func checkMemberLimit(uow *db.UnitOfWork, groupId uuid.UUID) error {
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("groups.id = ?", groupId).First(&models.Group{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("group not found")
+		}
+		return err
+	}
+
+	var totalCount int64
+
+	err = uow.DB.Model(&models.UserGroup{}).Where("user_groups.group_id = ?", groupId).Count(&totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	if totalCount >= maxGroupMembers {
+		return errors.New("maximum number of people already added to the group")
+	}
+
+	return nil
+}
+
+// getMemberRole will fetch role of specified user in the group.
+func getMemberRole(db *gorm.DB, userId, groupId uuid.UUID) (models.GroupRole, error) {
+	userGroup := models.UserGroup{}
+
+	err := db.Where("user_groups.user_id = ? AND user_groups.group_id = ?", userId, groupId).
+		First(&userGroup).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", errors.New("user not found in this group")
+		}
+		return "", err
+	}
+
+	return userGroup.Role, nil
+}
+
+// checkPermission will check if specified user is a member of the group whose role has the permission.
+func checkPermission(db *gorm.DB, userId, groupId uuid.UUID, permission models.GroupPermission) error {
+	role, err := getMemberRole(db, userId, groupId)
+	if err != nil {
+		return err
+	}
+
+	if !role.Can(permission) {
+		return errors.New(string(role) + " is not allowed to " + string(permission))
+	}
+
+	return nil
+}
+
+// transferOwnership will make specified member the owner of the group. The previous owner is made
+// an admin so that they can still manage the group. Placeholders cannot be made owner.
+func transferOwnership(uow *db.UnitOfWork, groupId, ownerId, successorId uuid.UUID) error {
+	err := doesRegisteredUserExist(uow.DB, successorId)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.UserGroup{}).
+		Where("user_groups.group_id = ? AND user_groups.user_id = ?", groupId, ownerId).
+		Update("Role", models.RoleAdmin).Error
+	if err != nil {
+		return err
+	}
+
+	return uow.DB.Model(&models.UserGroup{}).
+		Where("user_groups.group_id = ? AND user_groups.user_id = ?", groupId, successorId).
+		Update("Role", models.RoleOwner).Error
+}
+
+// doesRegisteredUserExist will check if specified user exists and is not a placeholder.
+func doesRegisteredUserExist(db *gorm.DB, userId uuid.UUID) error {
+	user := models.User{}
+
+	err := db.Where("users.id = ?", userId).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	if user.IsPlaceholder {
+		return errors.New("placeholder member is not allowed, it must be claimed by a registered user first")
+	}
+
+	return nil
+}
+
+// checkEmailVerified will check that specified user has verified their email. Unverified users cannot
+// invite others or be invited, so that an account registered with someone else's email is not useful.
+func checkEmailVerified(db *gorm.DB, userId uuid.UUID) error {
+	verified := []bool{}
+
+	err := db.Model(&models.User{}).Where("users.id = ?", userId).Limit(1).
+		Pluck("users.email_verified", &verified).Error
+	if err != nil {
+		return err
+	}
+
+	if len(verified) == 0 {
+		return errors.New("user not found")
+	}
+
+	if !verified[0] {
+		return errors.New("user has not verified their email")
+	}
+
+	return nil
+}
+
+// getLongestStandingMember will fetch the member with specified role who joined the group first,
+// excluding specified user. It returns nil if there is no such member.
+func getLongestStandingMember(uow *db.UnitOfWork, groupId, excludeUserId uuid.UUID,
+	role models.GroupRole) (*models.UserGroup, error) {
+
+	userGroups := []models.UserGroup{}
+
+	err := uow.DB.Joins("INNER JOIN users ON users.id = user_groups.user_id AND users.is_placeholder = ?", false).
+		Where("user_groups.group_id = ? AND user_groups.user_id != ? AND user_groups.role = ?",
+			groupId, excludeUserId, role).Order("user_groups.created_at").Limit(1).Find(&userGroups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userGroups) == 0 {
+		return nil, nil
+	}
+
+	return &userGroups[0], nil
+}
+
+// ensureOwner will make sure that specified group has an owner. If the owner has left the group the
+// longest-standing admin, or the longest-standing member if there are no admins, is made the owner.
+func ensureOwner(uow *db.UnitOfWork, groupId uuid.UUID) error {
+	var totalCount int64
+
+	err := uow.DB.Model(&models.UserGroup{}).
+		Where("user_groups.group_id = ? AND user_groups.role = ?", groupId, models.RoleOwner).
+		Count(&totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	if totalCount > 0 {
+		return nil
+	}
+
+	for _, role := range []models.GroupRole{models.RoleAdmin, models.RoleMember} {
+		successor, err := getLongestStandingMember(uow, groupId, uuid.Nil, role)
+		if err != nil {
+			return err
+		}
+
+		if successor != nil {
+			return uow.DB.Model(&models.UserGroup{}).Where("user_groups.id = ?", successor.Id).
+				Update("Role", models.RoleOwner).Error
+		}
+	}
+
+	return nil
+}
+
+// addMember will add specified user to the group with the role. If the user had left the group
+// earlier, their previous membership is restored so that group views show them only once. Amounts
+// of the member are in base currency of the group.
+func addMember(uow *db.UnitOfWork, userId, groupId uuid.UUID, role models.GroupRole) error {
+	baseCurrencies := []string{}
+
+	err := uow.DB.Model(&models.Group{}).Where("groups.id = ?", groupId).Limit(1).
+		Pluck("groups.base_currency", &baseCurrencies).Error
+	if err != nil {
+		return err
+	}
+
+	if len(baseCurrencies) == 0 {
+		return errors.New("group not found")
+	}
+
+	userGroups := []models.UserGroup{}
+
+	err = uow.DB.Unscoped().
+		Where("user_groups.user_id = ? AND user_groups.group_id = ? AND user_groups.deleted_at IS NOT NULL", userId, groupId).
+		Order("user_groups.deleted_at DESC").Limit(1).Find(&userGroups).Error
+	if err != nil {
+		return err
+	}
+
+	if len(userGroups) == 0 {
+		return uow.DB.Create(&models.UserGroup{
+			UserId:         userId,
+			GroupId:        groupId,
+			Role:           role,
+			IncomingAmount: models.NewMoney(0, baseCurrencies[0]),
+			OutgoingAmount: models.NewMoney(0, baseCurrencies[0]),
+		}).Error
+	}
+
+	return uow.DB.Unscoped().Model(&models.UserGroup{}).Where("user_groups.id = ?", userGroups[0].Id).
+		Updates(map[string]interface{}{
+			"DeletedAt":                nil,
+			"Role":                     role,
+			"incoming_amount_currency": baseCurrencies[0],
+			"outgoing_amount_currency": baseCurrencies[0],
+		}).Error
+}
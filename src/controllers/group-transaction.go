@@ -2,13 +2,16 @@ package controllers
 
 import (
 	"errors"
-	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/balance"
 	"github.com/shaileshhb/equisplit/src/db"
 	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/planner"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GroupTransactionController will contain all methods to be implemented by userGroupHistory controller
@@ -16,24 +19,52 @@ type GroupTransactionController interface {
 	Add(*models.GroupTransaction, ...*db.UnitOfWork) error
 	AddMulitple(transaction *[]models.GroupTransaction) error
 	MarkTransactionPaid(*models.GroupTransaction, uuid.UUID) error
+	Update(transaction *models.GroupTransaction, update *models.GroupTransactionUpdate, userId uuid.UUID) error
+	GetRevisions(revisions *[]models.GroupTransactionRevisionDTO, transactionId, userId uuid.UUID) error
 	GetTransactionDetails(userBalance *[]models.UserBalance, userId, groupId uuid.UUID) error
 	Delete(userId, transactionId uuid.UUID) error
+	AddExpenseTransaction(uow *db.UnitOfWork, transaction *models.GroupTransaction, baseAmount models.Money,
+		fxRate float64) error
+	DeleteExpenseTransactions(uow *db.UnitOfWork, expenseId uuid.UUID) error
+	GetSettlementPlan(transfers *[]models.SettlementTransfer, groupId uuid.UUID) error
+	ApplySettlementPlan(transfers *[]models.SettlementTransfer, userId, groupId uuid.UUID) error
 }
 
 type groupTransactionController struct {
-	db *gorm.DB
+	db            *gorm.DB
+	fxCon         FxRateController
+	settlementCon SettlementController
+	balance       balance.Engine
 }
 
 // NewGroupTransactionController will return new instance of GroupTransactionController.
 func NewGroupTransactionController(db *gorm.DB) GroupTransactionController {
 	return &groupTransactionController{
-		db: db,
+		db:            db,
+		fxCon:         NewFxRateController(db),
+		settlementCon: NewSettlementController(db),
+		balance:       balance.NewEngine(db),
 	}
 }
 
-// Add will add new transaction for specified group and user.
+// Add will add new transaction for specified group and user. Base amount and rate are always
+// converted from amount, whatever is set in the transaction.
 func (g *groupTransactionController) Add(transaction *models.GroupTransaction,
 	uows ...*db.UnitOfWork) error {
+	return g.add(transaction, nil, 0, uows...)
+}
+
+// AddExpenseTransaction will add transaction of an expense with specified base amount and rate.
+// Base amount is allocated from base amount of the expense, so that base amounts of its transactions
+// add up exactly to it.
+func (g *groupTransactionController) AddExpenseTransaction(uow *db.UnitOfWork, transaction *models.GroupTransaction,
+	baseAmount models.Money, fxRate float64) error {
+	return g.add(transaction, &baseAmount, fxRate, uow)
+}
+
+// add will add new transaction. Base amount is converted from amount unless it is specified.
+func (g *groupTransactionController) add(transaction *models.GroupTransaction, baseAmount *models.Money,
+	fxRate float64, uows ...*db.UnitOfWork) error {
 
 	err := g.doesUserExist(transaction.PayeeId)
 	if err != nil {
@@ -60,6 +91,19 @@ func (g *groupTransactionController) Add(transaction *models.GroupTransaction,
 		return err
 	}
 
+	// transactions of an expense are checked when the expense is added.
+	if len(uows) == 0 {
+		err = checkPermission(g.db, transaction.PayerId, transaction.GroupId, models.PermissionAddExpense)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = g.setBaseAmount(transaction, baseAmount, fxRate)
+	if err != nil {
+		return err
+	}
+
 	var uow *db.UnitOfWork
 
 	if len(uows) == 0 {
@@ -74,14 +118,8 @@ func (g *groupTransactionController) Add(transaction *models.GroupTransaction,
 		return err
 	}
 
-	// updates payer incoming amount.
-	err = g.setUserIncomingAmount(uow, transaction)
-	if err != nil {
-		return err
-	}
-
-	// updates payees outgoing amount.
-	err = g.setUserOutgoingAmount(uow, transaction)
+	// updates payer incoming amount and payee outgoing amount.
+	err = g.balance.Refresh(uow, transaction.GroupId, transaction.PayerId, transaction.PayeeId)
 	if err != nil {
 		return err
 	}
@@ -93,166 +131,261 @@ func (g *groupTransactionController) Add(transaction *models.GroupTransaction,
 	return nil
 }
 
-func (g *groupTransactionController) setUserIncomingAmount(uow *db.UnitOfWork, transaction *models.GroupTransaction) error {
-	fmt.Println("==================setUserIncomingAmount==============================")
-	payerAmount := struct {
-		Amount  float64
-		PayerId uuid.UUID
-	}{}
+// setBaseAmount will convert amount of the transaction into base currency of its group using the
+// rate of the transaction date, or set specified base amount and rate of an expense. Amount without
+// currency is considered to be in the base currency.
+func (g *groupTransactionController) setBaseAmount(transaction *models.GroupTransaction, baseAmount *models.Money,
+	fxRate float64) error {
+	group := models.Group{}
 
-	payerAmount.PayerId = transaction.PayerId
-
-	// set incoming for payer
-	err := uow.DB.Select("payer_id, sum(amount) AS amount").Where("payer_id = ? AND is_paid = ? AND is_adjusted = ?",
-		transaction.PayerId, false, false).Group("payer_id").Find(&models.GroupTransaction{}).Scan(&payerAmount).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
+	err := g.db.Where("groups.id = ?", transaction.GroupId).First(&group).Error
+	if err != nil {
 		return err
 	}
 
-	if payerAmount.PayerId != uuid.Nil {
-		err = uow.DB.Model(&models.UserGroup{}).Where("user_id = ? AND group_id = ?", transaction.PayerId,
-			transaction.GroupId).Updates(map[string]interface{}{
-			"IncomingAmount": payerAmount.Amount,
-		}).Error
-		if err != nil {
-			return err
-		}
+	err = transaction.Amount.SetDefaultCurrency(group.BaseCurrency)
+	if err != nil {
+		return err
 	}
 
-	payerAmount.Amount = 0
-	payerAmount.PayerId = transaction.PayeeId
+	if transaction.Date == nil {
+		now := time.Now()
+		transaction.Date = &now
+	}
 
-	// set incoming for payee
-	err = uow.DB.Select("payer_id, sum(amount) AS amount").Where("payer_id = ? AND is_paid = ? AND is_adjusted = ?",
-		transaction.PayeeId, false, false).Group("payer_id").Find(&models.GroupTransaction{}).
-		Scan(&payerAmount).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return err
+	if baseAmount != nil {
+		transaction.BaseAmount = *baseAmount
+		transaction.FxRate = fxRate
+		return nil
 	}
 
-	if payerAmount.PayerId != uuid.Nil {
-		err = uow.DB.Model(&models.UserGroup{}).Where("user_id = ? AND group_id = ?", transaction.PayeeId,
-			transaction.GroupId).Updates(map[string]interface{}{
-			"IncomingAmount": payerAmount.Amount,
-		}).Error
-		if err != nil {
-			return err
-		}
+	transaction.BaseAmount, transaction.FxRate, err = g.fxCon.Convert(transaction.Amount, group.BaseCurrency, *transaction.Date)
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func (g *groupTransactionController) setUserOutgoingAmount(uow *db.UnitOfWork, transaction *models.GroupTransaction) error {
-	fmt.Println("==================setUserOutgoingAmount==============================")
-	payeeAmount := struct {
-		Amount  float64
-		PayeeId uuid.UUID
-	}{}
+// AddMulitple will add new transaction for specified group.
+func (g *groupTransactionController) AddMulitple(transaction *[]models.GroupTransaction) error {
 
-	payeeAmount.PayeeId = transaction.PayerId
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
 
-	// set outcoming for payer
-	err := uow.DB.Select("payee_id, sum(amount)").Where("payee_id = ? AND is_paid = ? AND is_adjusted = ?",
-		transaction.PayerId, false, false).Group("payee_id").Find(&models.GroupTransaction{}).
-		Scan(&payeeAmount).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return err
-	}
+	for _, t := range *transaction {
+		err := checkPermission(g.db, t.PayerId, t.GroupId, models.PermissionAddExpense)
+		if err != nil {
+			return err
+		}
 
-	if payeeAmount.PayeeId != uuid.Nil {
-		err = uow.DB.Model(&models.UserGroup{}).Where("user_id = ? AND group_id = ?", transaction.PayerId,
-			transaction.GroupId).Updates(map[string]interface{}{
-			"OutgoingAmount": payeeAmount.Amount,
-		}).Error
+		err = g.Add(&t, uow)
 		if err != nil {
 			return err
 		}
 	}
 
-	payeeAmount.Amount = 0
-	payeeAmount.PayeeId = transaction.PayeeId
+	uow.Commit()
+	return nil
+}
+
+// MarkTransactionPaid will record a settlement for the outstanding amount of the transaction.
+// If payee marks it as paid the settlement is pending until payer confirms it, while if payer
+// marks it as paid it is confirmed and the transaction is paid right away.
+func (g *groupTransactionController) MarkTransactionPaid(transaction *models.GroupTransaction, userId uuid.UUID) error {
 
-	// set incoming for payee
-	err = uow.DB.Select("payee_id, sum(amount) AS amount").Where("payee_id = ? AND is_paid = ? AND is_adjusted = ?",
-		transaction.PayeeId, false, false).Group("payee_id").Find(&models.GroupTransaction{}).
-		Scan(&payeeAmount).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
+	err := g.doesGroupTransactionExist(transaction.Id)
+	if err != nil {
 		return err
 	}
 
-	if payeeAmount.PayeeId != uuid.Nil {
-		err = uow.DB.Model(&models.UserGroup{}).Where("user_id = ? AND group_id = ?", transaction.PayeeId,
-			transaction.GroupId).Updates(map[string]interface{}{
-			"OutgoingAmount": payeeAmount.Amount,
-		}).Error
-		if err != nil {
-			return err
+	err = g.db.Where("id = ? AND (payee_id = ? OR payer_id = ?)", transaction.Id, userId, userId).
+		First(transaction).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("only payer or payee can mark transaction as paid")
 		}
+		return err
 	}
 
-	return nil
+	if transaction.IsPaid {
+		return errors.New("transaction is already paid")
+	}
+
+	return g.settlementCon.Add(&models.Settlement{
+		FromUserId:     transaction.PayeeId,
+		ToUserId:       transaction.PayerId,
+		GroupId:        transaction.GroupId,
+		CreatedBy:      userId,
+		Amount:         transaction.Outstanding(),
+		Method:         models.SettlementOther,
+		TransactionIds: []uuid.UUID{transaction.Id},
+	})
 }
 
-// AddMulitple will add new transaction for specified group.
-func (g *groupTransactionController) AddMulitple(transaction *[]models.GroupTransaction) error {
+// Update will update specified fields of the transaction and store its previous version as a
+// revision. Only payer can update a transaction and only until it is settled.
+func (g *groupTransactionController) Update(transaction *models.GroupTransaction,
+	update *models.GroupTransactionUpdate, userId uuid.UUID) error {
+
+	err := g.doesGroupTransactionExist(transaction.Id)
+	if err != nil {
+		return err
+	}
 
 	uow := db.NewUnitOfWork(g.db)
 	defer uow.RollBack()
 
-	for _, t := range *transaction {
-		err := g.Add(&t, uow)
+	// lock the transaction so that concurrent updates do not overwrite each other.
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("group_transactions.id = ?", transaction.Id).First(transaction).Error
+	if err != nil {
+		return err
+	}
+
+	if transaction.PayerId != userId {
+		return errors.New("only payer can update a transaction")
+	}
+
+	if transaction.ExpenseId != nil {
+		return errors.New("transaction of an expense can only be updated by updating the expense")
+	}
+
+	if transaction.IsAdjusted || transaction.IsSettlement {
+		return errors.New("adjusted transaction cannot be updated")
+	}
+
+	if !transaction.PaidAmount.IsZero() {
+		return errors.New("transaction with settlements cannot be updated")
+	}
+
+	revision := models.GroupTransactionRevision{
+		GroupTransactionId: transaction.Id,
+		ChangedBy:          userId,
+		PayeeId:            transaction.PayeeId,
+		Amount:             transaction.Amount,
+		BaseAmount:         transaction.BaseAmount,
+		FxRate:             transaction.FxRate,
+		Date:               transaction.Date,
+		Description:        transaction.Description,
+	}
+
+	previousPayeeId := transaction.PayeeId
+	changedFields := []string{}
+
+	if update.PayeeId != nil && *update.PayeeId != transaction.PayeeId {
+		if *update.PayeeId == transaction.PayerId {
+			return errors.New("payer and payee cannot be same")
+		}
+
+		err = g.doesUserExistInGroup(*update.PayeeId, transaction.GroupId)
 		if err != nil {
 			return err
 		}
+
+		transaction.PayeeId = *update.PayeeId
+		changedFields = append(changedFields, "payee")
 	}
 
-	uow.Commit()
-	return nil
-}
+	if update.Amount != nil {
+		// amount without currency is in the currency of the transaction.
+		err = update.Amount.SetDefaultCurrency(transaction.Amount.Currency)
+		if err != nil {
+			return err
+		}
 
-// MarkTransactionPaid will mark the transaction has paid
-func (g *groupTransactionController) MarkTransactionPaid(transaction *models.GroupTransaction, payeeId uuid.UUID) error {
+		if update.Amount.Minor != transaction.Amount.Minor || update.Amount.Currency != transaction.Amount.Currency {
+			transaction.Amount = *update.Amount
+			changedFields = append(changedFields, "amount")
+		}
+	}
 
-	err := g.doesGroupTransactionExist(transaction.Id)
+	if update.Date != nil && (transaction.Date == nil || !update.Date.Equal(*transaction.Date)) {
+		transaction.Date = update.Date
+		changedFields = append(changedFields, "date")
+	}
+
+	if update.Description != nil && (transaction.Description == nil || *update.Description != *transaction.Description) {
+		transaction.Description = update.Description
+		changedFields = append(changedFields, "description")
+	}
+
+	if len(changedFields) == 0 {
+		return errors.New("no changes specified")
+	}
+
+	// amount and date both affect the base amount, so it is converted again.
+	err = g.setBaseAmount(transaction, nil, 0)
 	if err != nil {
 		return err
 	}
 
-	uow := db.NewUnitOfWork(g.db)
-	defer uow.RollBack()
+	var version int64
 
-	err = uow.DB.Where("id = ? AND payee_id = ?", transaction.Id, payeeId).
-		First(&models.GroupTransaction{}).Error
+	err = uow.DB.Model(&models.GroupTransactionRevision{}).
+		Where("group_transaction_revisions.group_transaction_id = ?", transaction.Id).Count(&version).Error
+	if err != nil {
+		return err
+	}
+
+	revision.Version = int(version) + 1
+	revision.ChangedFields = strings.Join(changedFields, ",")
+
+	err = uow.DB.Create(&revision).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return errors.New("only payee can mark transaction as paid")
-		}
 		return err
 	}
 
 	err = uow.DB.Model(&models.GroupTransaction{}).Where("group_transactions.id = ?", transaction.Id).
 		Updates(map[string]interface{}{
-			"IsPaid": true,
+			"payee_id":             transaction.PayeeId,
+			"amount_minor":         transaction.Amount.Minor,
+			"amount_currency":      transaction.Amount.Currency,
+			"base_amount_minor":    transaction.BaseAmount.Minor,
+			"base_amount_currency": transaction.BaseAmount.Currency,
+			"fx_rate":              transaction.FxRate,
+			"date":                 transaction.Date,
+			"description":          transaction.Description,
 		}).Error
 	if err != nil {
 		return err
 	}
 
-	err = uow.DB.Model(&models.GroupTransaction{}).Where("id = ?", transaction.Id).
-		First(&transaction).Error
+	// updates balances of payer and of both previous and new payee.
+	err = g.balance.Refresh(uow, transaction.GroupId, transaction.PayerId, previousPayeeId, transaction.PayeeId)
 	if err != nil {
 		return err
 	}
 
-	// updates payer incoming amount.
-	err = g.setUserIncomingAmount(uow, transaction)
+	uow.Commit()
+	return nil
+}
+
+// GetRevisions will fetch all previous versions of specified transaction, latest first.
+// Only members of the group of the transaction can fetch them.
+func (g *groupTransactionController) GetRevisions(revisions *[]models.GroupTransactionRevisionDTO,
+	transactionId, userId uuid.UUID) error {
+
+	transaction := models.GroupTransaction{}
+
+	err := g.db.Where("group_transactions.id = ?", transactionId).First(&transaction).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("transaction not found")
+		}
+		return err
+	}
+
+	err = g.doesUserExistInGroup(userId, transaction.GroupId)
 	if err != nil {
 		return err
 	}
 
-	// updates payees outgoing amount.
-	err = g.setUserOutgoingAmount(uow, transaction)
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Where("group_transaction_revisions.group_transaction_id = ?", transactionId).
+		Preload("ChangedByUser").Order("group_transaction_revisions.version DESC").Find(revisions).Error
 	if err != nil {
 		return err
 	}
@@ -271,13 +404,28 @@ func (g *groupTransactionController) GetTransactionDetails(userBalance *[]models
 	uow := db.NewUnitOfWork(g.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Select("payee_id AS user_id, group_id, sum(amount) AS amount").Table("group_transactions").
-		Preload("User").Where("payer_id = ? AND group_id = ? AND is_paid = ? AND is_adjusted = ?",
-		userId, groupId, false, false).Group("payee_id, group_id").Order("amount").Find(userBalance).Error
+	err = uow.DB.Select("payee_id AS user_id, group_id, sum(base_amount_minor - paid_amount_minor) AS amount_minor,"+
+		" base_amount_currency AS amount_currency").Table("group_transactions").Preload("User").
+		Where("payer_id = ? AND group_id = ? AND is_paid = ? AND is_adjusted = ? AND deleted_at IS NULL",
+			userId, groupId, false, false).
+		Group("payee_id, group_id, base_amount_currency").Order("amount_minor").Find(userBalance).Error
 	if err != nil {
 		return err
 	}
 
+	for index := range *userBalance {
+		(*userBalance)[index].OriginalAmounts = []models.Money{}
+
+		err = uow.DB.Model(&models.GroupTransaction{}).
+			Select("sum(amount_minor) AS minor, amount_currency AS currency").
+			Where("payer_id = ? AND payee_id = ? AND group_id = ? AND is_paid = ? AND is_adjusted = ?",
+				userId, (*userBalance)[index].UserId, groupId, false, false).
+			Group("amount_currency").Order("amount_currency").Scan(&(*userBalance)[index].OriginalAmounts).Error
+		if err != nil {
+			return err
+		}
+	}
+
 	uow.Commit()
 	return nil
 }
@@ -298,8 +446,10 @@ func (g *groupTransactionController) Delete(userId, transactionId uuid.UUID) err
 	uow := db.NewUnitOfWork(g.db)
 	defer uow.RollBack()
 
+	transaction := models.GroupTransaction{}
+
 	err = uow.DB.Model(&models.GroupTransaction{}).Where("group_transactions.id = ? AND group_transactions.payer_id = ?", transactionId, userId).
-		First(&models.GroupTransaction{}).Error
+		First(&transaction).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return errors.New("only payer can delete a transaction")
@@ -307,18 +457,214 @@ func (g *groupTransactionController) Delete(userId, transactionId uuid.UUID) err
 		return err
 	}
 
-	err = uow.DB.Where("group_transactions.id = ?", transactionId).
+	if !transaction.PaidAmount.IsZero() {
+		return errors.New("transaction with settlements cannot be deleted")
+	}
+
+	err = uow.DB.Delete(&models.GroupTransaction{}, transactionId).Error
+	if err != nil {
+		return err
+	}
+
+	// updates payer incoming amount and payee outgoing amount.
+	err = g.balance.Refresh(uow, transaction.GroupId, transaction.PayerId, transaction.PayeeId)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// DeleteExpenseTransactions will delete all transactions generated for specified expense.
+func (g *groupTransactionController) DeleteExpenseTransactions(uow *db.UnitOfWork, expenseId uuid.UUID) error {
+	transactions := []models.GroupTransaction{}
+
+	err := uow.DB.Where("group_transactions.expense_id = ?", expenseId).Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	for index := range transactions {
+		if !transactions[index].PaidAmount.IsZero() {
+			return errors.New("expense with settlements cannot be modified")
+		}
+	}
+
+	err = uow.DB.Where("group_transactions.expense_id = ?", expenseId).Delete(&models.GroupTransaction{}).Error
+	if err != nil {
+		return err
+	}
+
+	userIds := []uuid.UUID{}
+	for index := range transactions {
+		userIds = append(userIds, transactions[index].PayerId, transactions[index].PayeeId)
+	}
+
+	err = g.balance.Refresh(uow, transactions[0].GroupId, userIds...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSettlementPlan will fetch a small set of transfers which settles all debts of specified group.
+func (g *groupTransactionController) GetSettlementPlan(transfers *[]models.SettlementTransfer, groupId uuid.UUID) error {
+	err := g.doesGroupExist(groupId)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	pendingTransactions := []models.GroupTransaction{}
+
+	err = uow.DB.Where("group_id = ? AND is_paid = ? AND is_adjusted = ?", groupId, false, false).
+		Find(&pendingTransactions).Error
+	if err != nil {
+		return err
+	}
+
+	err = g.createSettlementPlan(uow, transfers, groupId, pendingTransactions)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// ApplySettlementPlan will mark all pending transactions of specified group as adjusted and
+// replace them with the transfers of the settlement plan. Only members who can resolve balances
+// can apply the plan.
+func (g *groupTransactionController) ApplySettlementPlan(transfers *[]models.SettlementTransfer, userId, groupId uuid.UUID) error {
+	err := g.doesGroupExist(groupId)
+	if err != nil {
+		return err
+	}
+
+	err = checkPermission(g.db, userId, groupId, models.PermissionResolveBalances)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	pendingTransactions := []models.GroupTransaction{}
+
+	// lock pending transactions so that they are not modified while the plan is applied.
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("group_id = ? AND is_paid = ? AND is_adjusted = ?", groupId, false, false).
+		Find(&pendingTransactions).Error
+	if err != nil {
+		return err
+	}
+
+	if len(pendingTransactions) == 0 {
+		return errors.New("no pending transactions found")
+	}
+
+	err = g.createSettlementPlan(uow, transfers, groupId, pendingTransactions)
+	if err != nil {
+		return err
+	}
+
+	pendingTransactionIds := make([]uuid.UUID, len(pendingTransactions))
+	for index := range pendingTransactions {
+		pendingTransactionIds[index] = pendingTransactions[index].Id
+	}
+
+	err = uow.DB.Model(&models.GroupTransaction{}).
+		Where("group_transactions.id IN (?)", pendingTransactionIds).
 		Updates(map[string]interface{}{
-			"DeletedAt": time.Now(),
+			"IsAdjusted": true,
 		}).Error
 	if err != nil {
 		return err
 	}
 
+	description := "Settlement plan"
+	now := time.Now()
+
+	for _, transfer := range *transfers {
+		err = uow.DB.Create(&models.GroupTransaction{
+			PayerId:      transfer.ToUserId,
+			PayeeId:      transfer.FromUserId,
+			GroupId:      groupId,
+			Amount:       transfer.Amount,
+			BaseAmount:   transfer.Amount,
+			FxRate:       1,
+			Date:         &now,
+			Description:  &description,
+			IsSettlement: true,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// net position of every member has changed, so all members of the group are refreshed.
+	err = g.balance.Refresh(uow, groupId)
+	if err != nil {
+		return err
+	}
+
 	uow.Commit()
 	return nil
 }
 
+// createSettlementPlan will compute net position of every member from pending transactions
+// of the group and create the settlement plan from it.
+func (g *groupTransactionController) createSettlementPlan(uow *db.UnitOfWork, transfers *[]models.SettlementTransfer,
+	groupId uuid.UUID, pendingTransactions []models.GroupTransaction) error {
+
+	var err error
+
+	// all balances of a group are in its base currency.
+	positions := make(map[uuid.UUID]int64)
+	currency := models.DefaultCurrency
+
+	for _, t := range pendingTransactions {
+		positions[t.PayerId] += t.Outstanding().Minor
+		positions[t.PayeeId] -= t.Outstanding().Minor
+		currency = t.BaseAmount.Currency
+	}
+
+	*transfers = []models.SettlementTransfer{}
+
+	for _, transfer := range planner.Plan(positions) {
+		*transfers = append(*transfers, models.SettlementTransfer{
+			GroupId:    groupId,
+			FromUserId: transfer.From,
+			ToUserId:   transfer.To,
+			Amount:     models.NewMoney(transfer.Amount, currency),
+		})
+	}
+
+	for index := range *transfers {
+		(*transfers)[index].FromUser = &models.UserDTO{}
+		err = uow.DB.Where("users.id = ?", (*transfers)[index].FromUserId).First((*transfers)[index].FromUser).Error
+		if err != nil {
+			return err
+		}
+
+		(*transfers)[index].ToUser = &models.UserDTO{}
+		err = uow.DB.Where("users.id = ?", (*transfers)[index].ToUserId).First((*transfers)[index].ToUser).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // doesUserExist will check if specified user exist or not.
 func (g *groupTransactionController) doesUserExist(userId uuid.UUID) error {
 	err := g.db.Where("users.id = ?", userId).First(&models.User{}).Error
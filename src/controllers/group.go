@@ -4,16 +4,18 @@ import (
 	"errors"
 
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"github.com/shaileshhb/equisplit/src/db"
 	"github.com/shaileshhb/equisplit/src/models"
 	"github.com/shaileshhb/equisplit/src/util"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GroupController interface {
 	CreateGroup(group *models.Group) error
-	UpdateGroup(group *models.Group) error
-	DeleteGroup(group *models.Group) error
+	UpdateGroup(group *models.Group, update *models.GroupUpdate, userId uuid.UUID) error
+	DeleteGroup(group *models.Group, userId uuid.UUID) error
 	GetUserGroups(group *[]models.GroupDTO, userId uuid.UUID, totalCount *int64, parser *util.Parser) error
 }
 
@@ -54,30 +56,29 @@ func (g *groupController) CreateGroup(group *models.Group) error {
 	}
 
 	err = uow.DB.Create(&models.UserGroup{
-		UserId:  group.CreatedBy,
-		GroupId: group.Id,
+		UserId:         group.CreatedBy,
+		GroupId:        group.Id,
+		Role:           models.RoleOwner,
+		IncomingAmount: models.NewMoney(0, group.BaseCurrency),
+		OutgoingAmount: models.NewMoney(0, group.BaseCurrency),
 	}).Error
 	if err != nil {
 		return err
 	}
 
-	// token, err := security.GenerateInviteJwt(group.Id)
-	// if err != nil {
-	// 	return err
-	// }
-
 	uow.Commit()
 	return nil
 }
 
-// UpdateGroup will update specified group details.
-func (g *groupController) UpdateGroup(group *models.Group) error {
+// UpdateGroup will update the details of specified group which are present in update. Renaming the
+// group and changing its settings are allowed only for roles which have the permission.
+func (g *groupController) UpdateGroup(group *models.Group, update *models.GroupUpdate, userId uuid.UUID) error {
 	err := g.doesGroupExist(group.Id)
 	if err != nil {
 		return err
 	}
 
-	err = g.doesUserExist(group.CreatedBy)
+	err = g.doesUserExist(userId)
 	if err != nil {
 		return err
 	}
@@ -85,34 +86,102 @@ func (g *groupController) UpdateGroup(group *models.Group) error {
 	uow := db.NewUnitOfWork(g.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Updates(group).Error
+	// lock the group so that concurrent updates do not overwrite each other.
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("groups.id = ?", group.Id).First(group).Error
 	if err != nil {
 		return err
 	}
 
+	previousBaseCurrency := group.BaseCurrency
+
+	if update.Name != nil && *update.Name != group.Name {
+		err = checkPermission(uow.DB, userId, group.Id, models.PermissionRenameGroup)
+		if err != nil {
+			return err
+		}
+
+		group.Name = *update.Name
+	}
+
+	if update.Tag != nil && *update.Tag != lo.FromPtr(group.Tag) {
+		err = checkPermission(uow.DB, userId, group.Id, models.PermissionChangeSettings)
+		if err != nil {
+			return err
+		}
+
+		group.Tag = lo.EmptyableToPtr(*update.Tag)
+	}
+
+	if update.BaseCurrency != nil && *update.BaseCurrency != group.BaseCurrency {
+		err = checkPermission(uow.DB, userId, group.Id, models.PermissionChangeSettings)
+		if err != nil {
+			return err
+		}
+
+		var totalCount int64
+		err = uow.DB.Model(&models.GroupTransaction{}).Where("group_transactions.group_id = ?", group.Id).
+			Count(&totalCount).Error
+		if err != nil {
+			return err
+		}
+
+		if totalCount > 0 {
+			return errors.New("base currency cannot be changed after transactions are added")
+		}
+
+		group.BaseCurrency = *update.BaseCurrency
+	}
+
+	err = uow.DB.Model(&models.Group{}).Where("groups.id = ?", group.Id).
+		Select("Name", "Tag", "BaseCurrency").Updates(group).Error
+	if err != nil {
+		return err
+	}
+
+	// group without transactions has no amounts, so only their currency is changed.
+	if group.BaseCurrency != previousBaseCurrency {
+		err = uow.DB.Model(&models.Group{}).Where("groups.id = ?", group.Id).
+			Update("total_spent_currency", group.BaseCurrency).Error
+		if err != nil {
+			return err
+		}
+
+		err = uow.DB.Model(&models.UserGroup{}).Where("user_groups.group_id = ?", group.Id).
+			Updates(map[string]interface{}{
+				"incoming_amount_currency": group.BaseCurrency,
+				"outgoing_amount_currency": group.BaseCurrency,
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
 	uow.Commit()
 	return nil
 }
 
-// DeleteGroup will delete specified group.
-func (g *groupController) DeleteGroup(group *models.Group) error {
+// DeleteGroup will delete specified group. Only owner can delete a group. If the owner has already
+// left the group, a new owner is promoted first so that the group can still be deleted. Promotion is
+// rolled back if the user is not allowed to delete the group.
+func (g *groupController) DeleteGroup(group *models.Group, userId uuid.UUID) error {
 	err := g.doesGroupExist(group.Id)
 	if err != nil {
 		return err
 	}
 
-	err = g.db.
-		Where("groups.`created_by` = ? AND groups.id = ?", group.CreatedBy, group.Id).
-		First(&models.Group{}).Error
+	uow := db.NewUnitOfWork(g.db)
+	defer uow.RollBack()
+
+	err = ensureOwner(uow, group.Id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return errors.New("only admin can delete this group")
-		}
 		return err
 	}
 
-	uow := db.NewUnitOfWork(g.db)
-	defer uow.RollBack()
+	err = checkPermission(uow.DB, userId, group.Id, models.PermissionDeleteGroup)
+	if err != nil {
+		return err
+	}
 
 	err = uow.DB.Unscoped().Delete(&models.Group{}, group.Id).Error
 	if err != nil {
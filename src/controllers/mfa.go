@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// totpIssuer is the name shown for the account in authenticator apps.
+const totpIssuer = "EquiSplit"
+
+type MfaController interface {
+	EnrollTotp(userId uuid.UUID, enrolment *models.TotpEnrolment) error
+	EnableTotp(userId uuid.UUID, code string, recoveryCodes *[]string) error
+	DisableTotp(userId uuid.UUID, factor *models.SecondFactor) error
+	RegenerateRecoveryCodes(userId uuid.UUID, factor *models.SecondFactor, recoveryCodes *[]string) error
+}
+
+type mfaController struct {
+	db *gorm.DB
+}
+
+// NewMfaController will return new instance of MfaController.
+func NewMfaController(db *gorm.DB) MfaController {
+	return &mfaController{
+		db: db,
+	}
+}
+
+// EnrollTotp will create a new TOTP secret for specified user, which they add to their authenticator
+// app. Two-factor authentication is not enabled until the user verifies a code of the secret with
+// EnableTotp. Enrolling again replaces the secret which is not enabled yet.
+func (m *mfaController) EnrollTotp(userId uuid.UUID, enrolment *models.TotpEnrolment) error {
+	uow := db.NewUnitOfWork(m.db)
+	defer uow.RollBack()
+
+	user, err := m.getUser(uow, userId)
+	if err != nil {
+		return err
+	}
+
+	if user.TotpEnabled {
+		return errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.User{}).Where("users.id = ?", userId).
+		Updates(map[string]interface{}{
+			"TotpSecret":      secret,
+			"TotpLastCounter": 0,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	enrolment.Secret = secret
+	enrolment.URI = security.TOTPURI(secret, totpIssuer, user.Email)
+
+	enrolment.QRCode, err = qrcode.Encode(enrolment.URI, qrcode.Medium, 256)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// EnableTotp will turn on two-factor authentication for specified user if the code matches the secret
+// they enrolled, and set the recovery codes created for them. Recovery codes are shown only once.
+func (m *mfaController) EnableTotp(userId uuid.UUID, code string, recoveryCodes *[]string) error {
+	uow := db.NewUnitOfWork(m.db)
+	defer uow.RollBack()
+
+	user, err := m.getUser(uow, userId)
+	if err != nil {
+		return err
+	}
+
+	if user.TotpEnabled {
+		return errors.New("two-factor authentication is already enabled")
+	}
+
+	if user.TotpSecret == nil {
+		return errors.New("two-factor authentication must be enrolled first")
+	}
+
+	err = verifySecondFactor(uow, &user, &models.SecondFactor{Code: code})
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.User{}).Where("users.id = ?", userId).Update("TotpEnabled", true).Error
+	if err != nil {
+		return err
+	}
+
+	err = m.createRecoveryCodes(uow, userId, recoveryCodes)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// DisableTotp will turn off two-factor authentication for specified user after checking their second
+// factor, and remove their secret and recovery codes.
+func (m *mfaController) DisableTotp(userId uuid.UUID, factor *models.SecondFactor) error {
+	err := factor.Validate()
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(m.db)
+	defer uow.RollBack()
+
+	user, err := m.getUser(uow, userId)
+	if err != nil {
+		return err
+	}
+
+	if !user.TotpEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	err = verifySecondFactor(uow, &user, factor)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.User{}).Where("users.id = ?", userId).
+		Updates(map[string]interface{}{
+			"TotpEnabled":     false,
+			"TotpSecret":      nil,
+			"TotpLastCounter": 0,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Where("recovery_codes.user_id = ?", userId).Delete(&models.RecoveryCode{}).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// RegenerateRecoveryCodes will replace recovery codes of specified user with new ones after checking
+// their second factor.
+func (m *mfaController) RegenerateRecoveryCodes(userId uuid.UUID, factor *models.SecondFactor,
+	recoveryCodes *[]string) error {
+
+	err := factor.Validate()
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(m.db)
+	defer uow.RollBack()
+
+	user, err := m.getUser(uow, userId)
+	if err != nil {
+		return err
+	}
+
+	if !user.TotpEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	err = verifySecondFactor(uow, &user, factor)
+	if err != nil {
+		return err
+	}
+
+	err = m.createRecoveryCodes(uow, userId, recoveryCodes)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// getUser will lock and fetch specified user, so that the same TOTP code cannot be used by two
+// requests at the same time.
+func (m *mfaController) getUser(uow *db.UnitOfWork, userId uuid.UUID) (models.User, error) {
+	user := models.User{}
+
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Where("users.id = ?", userId).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user, errors.New("user not found")
+		}
+		return user, err
+	}
+
+	return user, nil
+}
+
+// createRecoveryCodes will replace all recovery codes of the user with RecoveryCodeCount new codes.
+func (m *mfaController) createRecoveryCodes(uow *db.UnitOfWork, userId uuid.UUID, recoveryCodes *[]string) error {
+	err := uow.DB.Where("recovery_codes.user_id = ?", userId).Delete(&models.RecoveryCode{}).Error
+	if err != nil {
+		return err
+	}
+
+	codes := make([]models.RecoveryCode, models.RecoveryCodeCount)
+	*recoveryCodes = make([]string, models.RecoveryCodeCount)
+
+	for i := range codes {
+		code, hash, err := security.GenerateRecoveryCode()
+		if err != nil {
+			return err
+		}
+
+		(*recoveryCodes)[i] = code
+		codes[i] = models.RecoveryCode{
+			UserId:   userId,
+			CodeHash: hash,
+		}
+	}
+
+	return uow.DB.Create(&codes).Error
+}
+
+// verifySecondFactor will check the TOTP code or the recovery code of specified user. Code is
+// marked as used so that it cannot be used again. User must be locked by the caller.
+func verifySecondFactor(uow *db.UnitOfWork, user *models.User, factor *models.SecondFactor) error {
+	if factor.Code != "" {
+		if user.TotpSecret == nil {
+			return errors.New("two-factor authentication is not enabled")
+		}
+
+		counter, ok := security.ValidateTOTP(*user.TotpSecret, factor.Code, time.Now(), user.TotpLastCounter)
+		if !ok {
+			return errors.New("invalid code")
+		}
+
+		user.TotpLastCounter = counter
+
+		return uow.DB.Model(&models.User{}).Where("users.id = ?", user.Id).
+			Update("TotpLastCounter", counter).Error
+	}
+
+	result := uow.DB.Model(&models.RecoveryCode{}).
+		Where("recovery_codes.user_id = ? AND recovery_codes.code_hash = ? AND recovery_codes.used_at IS NULL",
+			user.Id, security.HashRecoveryCode(factor.RecoveryCode)).
+		Update("UsedAt", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("invalid recovery code")
+	}
+
+	return nil
+}
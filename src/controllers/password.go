@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/mail"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordController interface {
+	ForgotPassword(email string) error
+	ResetPassword(reset *models.PasswordReset) error
+}
+
+type passwordController struct {
+	db     *gorm.DB
+	mailer mail.Sender
+}
+
+// NewPasswordController will return new instance of PasswordController.
+func NewPasswordController(db *gorm.DB, mailer mail.Sender) PasswordController {
+	return &passwordController{
+		db:     db,
+		mailer: mailer,
+	}
+}
+
+// ForgotPassword will email a password reset token to the user with specified email. Previous tokens
+// of the user can no longer be used. Nothing is sent if the email is not registered, and no error is
+// returned, so that it cannot be used to find out who is registered.
+func (p *passwordController) ForgotPassword(email string) error {
+	if email == "" {
+		return errors.New("email must be specified")
+	}
+
+	uow := db.NewUnitOfWork(p.db)
+	defer uow.RollBack()
+
+	user := models.User{}
+
+	err := uow.DB.Where("users.email = ? AND users.is_placeholder = ?", email, false).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	err = p.invalidateTokens(uow, user.Id)
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := security.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	resetToken := models.PasswordResetToken{
+		UserId:    user.Id,
+		TokenHash: hash,
+		ExpiresOn: time.Now().Add(models.PasswordResetExpiry),
+	}
+
+	err = uow.DB.Create(&resetToken).Error
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Name":    user.Name,
+		"Token":   token,
+		"Minutes": int(models.PasswordResetExpiry.Minutes()),
+	}
+
+	// link is sent only if the url of the app is known, otherwise the token has to be entered.
+	if appURL := os.Getenv("APP_URL"); appURL != "" {
+		data["Link"] = appURL + "/reset-password?token=" + token
+	}
+
+	message, err := mail.Render(user.Email, "password-reset", data)
+	if err != nil {
+		return err
+	}
+
+	err = p.mailer.Send(message)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// ResetPassword will set the new password of the user whose token is specified, if the token is not
+// used or expired. The token and all other tokens of the user can no longer be used, and the user is
+// logged out of all existing sessions.
+func (p *passwordController) ResetPassword(reset *models.PasswordReset) error {
+	err := reset.Validate()
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(p.db)
+	defer uow.RollBack()
+
+	resetToken := models.PasswordResetToken{}
+
+	// token is locked so that it cannot be used twice.
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("password_reset_tokens.token_hash = ?", security.HashToken(reset.Token)).First(&resetToken).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("invalid or expired reset token")
+		}
+		return err
+	}
+
+	if !resetToken.IsValid() {
+		return errors.New("invalid or expired reset token")
+	}
+
+	password, err := security.HashPassword(reset.Password)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.User{}).Where("users.id = ?", resetToken.UserId).
+		Updates(map[string]interface{}{
+			"Password":          string(password),
+			"PasswordChangedAt": time.Now(),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = p.invalidateTokens(uow, resetToken.UserId)
+	if err != nil {
+		return err
+	}
+
+	err = revokeSessions(uow, resetToken.UserId, revokePasswordReset, nil)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// invalidateTokens will mark all unused reset tokens of the user as used.
+func (p *passwordController) invalidateTokens(uow *db.UnitOfWork, userId uuid.UUID) error {
+	return uow.DB.Model(&models.PasswordResetToken{}).
+		Where("password_reset_tokens.user_id = ? AND password_reset_tokens.used_at IS NULL", userId).
+		Update("UsedAt", time.Now()).Error
+}
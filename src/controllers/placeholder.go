@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/balance"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PlaceholderController interface {
+	Add(placeholder *models.User, groupId, userId uuid.UUID) error
+	Claim(placeholderId, userId uuid.UUID, uows ...*db.UnitOfWork) error
+	GetClaimable(placeholders *[]models.UserGroupDTO, userId uuid.UUID) error
+}
+
+type placeholderController struct {
+	db      *gorm.DB
+	balance balance.Engine
+}
+
+// NewPlaceholderController will return new instance of PlaceholderController.
+func NewPlaceholderController(db *gorm.DB) PlaceholderController {
+	return &placeholderController{
+		db:      db,
+		balance: balance.NewEngine(db),
+	}
+}
+
+// placeholderReferences are the columns which refer to a user that a placeholder can be part of.
+// They are moved to the user who claims the placeholder. Placeholder cannot login, so it can never
+// be the creator of an expense, transaction or settlement.
+var placeholderReferences = []struct {
+	table  string
+	column string
+}{
+	{"expenses", "payer_id"},
+	{"expense_splits", "user_id"},
+	{"group_transactions", "payer_id"},
+	{"group_transactions", "payee_id"},
+	{"group_transaction_revisions", "payee_id"},
+	{"settlements", "from_user_id"},
+	{"settlements", "to_user_id"},
+	{"member_departures", "user_id"},
+	{"member_departures", "reassigned_to"},
+}
+
+// Add will add a placeholder member with specified name to the group.
+func (p *placeholderController) Add(placeholder *models.User, groupId, userId uuid.UUID) error {
+	err := placeholder.ValidatePlaceholder()
+	if err != nil {
+		return err
+	}
+
+	err = checkPermission(p.db, userId, groupId, models.PermissionInvite)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(p.db)
+	defer uow.RollBack()
+
+	err = checkMemberLimit(uow, groupId)
+	if err != nil {
+		return err
+	}
+
+	placeholder.IsPlaceholder = true
+	placeholder.ClaimedBy = nil
+	placeholder.Password = ""
+
+	// email is omitted so that it is stored as NULL.
+	err = uow.DB.Omit("Email").Create(placeholder).Error
+	if err != nil {
+		return err
+	}
+
+	err = addMember(uow, placeholder.Id, groupId, models.RoleMember)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Claim will replace specified placeholder with the user in all of its transactions, expenses and
+// settlements and in the membership of its group, and update the balances, all in the same unit of
+// work. User can claim the placeholder if their email matches the claim email of the placeholder or
+// they have accepted an invitation for it.
+func (p *placeholderController) Claim(placeholderId, userId uuid.UUID, uows ...*db.UnitOfWork) error {
+	var uow *db.UnitOfWork
+
+	if len(uows) == 0 {
+		uow = db.NewUnitOfWork(p.db)
+		defer uow.RollBack()
+	} else {
+		uow = uows[0]
+	}
+
+	user := models.User{}
+
+	err := uow.DB.Where("users.id = ?", userId).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	if user.IsPlaceholder {
+		return errors.New("placeholder cannot claim another placeholder")
+	}
+
+	placeholder := models.User{}
+
+	// placeholder is locked so that it cannot be claimed twice.
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("users.id = ? AND users.is_placeholder = ?", placeholderId, true).First(&placeholder).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("placeholder not found")
+		}
+		return err
+	}
+
+	err = p.canClaim(uow, &placeholder, &user)
+	if err != nil {
+		return err
+	}
+
+	membership := models.UserGroup{}
+
+	// membership is fetched even if the placeholder has left the group, so that the user still
+	// inherits its history.
+	err = uow.DB.Unscoped().Where("user_groups.user_id = ?", placeholderId).First(&membership).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("placeholder is not a member of any group")
+		}
+		return err
+	}
+
+	err = p.moveMembership(uow, &membership, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, reference := range placeholderReferences {
+		err = uow.DB.Table(reference.table).Where(reference.column+" = ?", placeholderId).
+			Update(reference.column, userId).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// transactions between the user and the placeholder are now transactions of the user with themselves.
+	err = uow.DB.Model(&models.GroupTransaction{}).
+		Where("group_transactions.payer_id = ? AND group_transactions.payee_id = ?", userId, userId).
+		Where("group_transactions.is_paid = ? AND group_transactions.is_adjusted = ?", false, false).
+		Update("IsAdjusted", true).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.User{}).Where("users.id = ?", placeholderId).
+		Updates(map[string]interface{}{
+			"ClaimedBy": userId,
+			"DeletedAt": time.Now(),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = p.balance.Refresh(uow, membership.GroupId)
+	if err != nil {
+		return err
+	}
+
+	if len(uows) == 0 {
+		uow.Commit()
+	}
+
+	return nil
+}
+
+// canClaim will check if the user can claim specified placeholder. Email of the user must be verified,
+// otherwise anyone could register with the claim email and take over the placeholder.
+func (p *placeholderController) canClaim(uow *db.UnitOfWork, placeholder, user *models.User) error {
+	if placeholder.ClaimedBy != nil {
+		return errors.New("placeholder is already claimed")
+	}
+
+	err := checkEmailVerified(uow.DB, user.Id)
+	if err != nil {
+		return err
+	}
+
+	if placeholder.ClaimEmail != nil && strings.EqualFold(*placeholder.ClaimEmail, user.Email) {
+		return nil
+	}
+
+	var totalCount int64
+
+	err = uow.DB.Model(&models.UserInvitation{}).
+		Where("user_invitations.user_id = ? AND user_invitations.placeholder_id = ? AND user_invitations.status = ?",
+			user.Id, placeholder.Id, models.InvitationAccepted).
+		Count(&totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	if totalCount == 0 {
+		return errors.New("placeholder can be claimed only with matching email or an invitation")
+	}
+
+	return nil
+}
+
+// moveMembership will make the user a member of the group of the placeholder in place of the
+// placeholder. If the user is already a member, membership of the placeholder is removed. Nothing is
+// changed if the placeholder has left the group.
+func (p *placeholderController) moveMembership(uow *db.UnitOfWork, membership *models.UserGroup, userId uuid.UUID) error {
+	if membership.DeletedAt.Valid {
+		return nil
+	}
+
+	var totalCount int64
+
+	err := uow.DB.Model(&models.UserGroup{}).
+		Where("user_groups.user_id = ? AND user_groups.group_id = ?", userId, membership.GroupId).
+		Count(&totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	if totalCount > 0 {
+		return uow.DB.Delete(&models.UserGroup{}, membership.Id).Error
+	}
+
+	return uow.DB.Model(&models.UserGroup{}).Where("user_groups.id = ?", membership.Id).
+		Update("UserId", userId).Error
+}
+
+// GetClaimable will fetch unclaimed placeholders, with their group, whose claim email matches email
+// of specified user. Nothing is claimable until the user has verified their email.
+func (p *placeholderController) GetClaimable(placeholders *[]models.UserGroupDTO, userId uuid.UUID) error {
+	user := models.User{}
+
+	err := p.db.Where("users.id = ?", userId).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	if !user.EmailVerified {
+		return nil
+	}
+
+	uow := db.NewUnitOfWork(p.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Joins("INNER JOIN users ON users.id = user_groups.user_id").
+		Where("users.is_placeholder = ? AND users.claimed_by IS NULL AND users.deleted_at IS NULL", true).
+		Where("LOWER(users.claim_email) = LOWER(?)", user.Email).
+		Preload("User").Preload("Group").Find(placeholders).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reasons for which a session is revoked.
+const (
+	revokeLogout        = "logout"
+	revokePasswordReset = "password_reset"
+	revokeTokenReuse    = "refresh_token_reuse"
+	revokeOthers        = "logout_everywhere_else"
+)
+
+type SessionController interface {
+	Create(session *models.Session, tokens *models.AuthTokens, uows ...*db.UnitOfWork) error
+	Refresh(refreshToken string, tokens *models.AuthTokens) error
+	Revoke(sessionId, userId uuid.UUID) error
+	RevokeOthers(sessionId, userId uuid.UUID) error
+	GetSessions(sessions *[]models.SessionDTO, userId, currentSessionId uuid.UUID) error
+}
+
+type sessionController struct {
+	db *gorm.DB
+}
+
+// NewSessionController will return new instance of SessionController.
+func NewSessionController(db *gorm.DB) SessionController {
+	return &sessionController{
+		db: db,
+	}
+}
+
+// Create will start specified session for its user and set its login and refresh tokens in tokens.
+// User agent and IP of the session are those of the device the user logged in from.
+func (s *sessionController) Create(session *models.Session, tokens *models.AuthTokens, uows ...*db.UnitOfWork) error {
+	var uow *db.UnitOfWork
+
+	if len(uows) == 0 {
+		uow = db.NewUnitOfWork(s.db)
+		defer uow.RollBack()
+	} else {
+		uow = uows[0]
+	}
+
+	now := time.Now()
+	session.LastSeenAt = &now
+	session.RevokedAt = nil
+	session.RevokeReason = ""
+
+	err := uow.DB.Create(session).Error
+	if err != nil {
+		return err
+	}
+
+	err = s.issueTokens(uow, session, tokens)
+	if err != nil {
+		return err
+	}
+
+	if len(uows) == 0 {
+		uow.Commit()
+	}
+
+	return nil
+}
+
+// Refresh will exchange specified refresh token for new login and refresh tokens of its session. If
+// a refresh token which was already exchanged is used again, it might have been stolen, so the session
+// is revoked and the user has to login again.
+func (s *sessionController) Refresh(refreshToken string, tokens *models.AuthTokens) error {
+	if refreshToken == "" {
+		return errors.New("refresh token must be specified")
+	}
+
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	token := models.RefreshToken{}
+
+	// token is locked so that it cannot be exchanged twice at the same time.
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("refresh_tokens.token_hash = ?", security.HashToken(refreshToken)).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("invalid refresh token")
+		}
+		return err
+	}
+
+	session := models.Session{}
+
+	err = uow.DB.Where("sessions.id = ?", token.SessionId).First(&session).Error
+	if err != nil {
+		return err
+	}
+
+	if !session.IsActive() {
+		return errors.New("session is revoked")
+	}
+
+	if token.RotatedAt != nil {
+		err = revokeSessions(uow, session.UserId, revokeTokenReuse, &session.Id)
+		if err != nil {
+			return err
+		}
+
+		// revocation is saved even though the refresh fails.
+		uow.Commit()
+		return errors.New("refresh token was already used, please login again")
+	}
+
+	if !token.ExpiresOn.After(time.Now()) {
+		return errors.New("refresh token has expired, please login again")
+	}
+
+	err = uow.DB.Model(&models.RefreshToken{}).Where("refresh_tokens.id = ?", token.Id).
+		Update("RotatedAt", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.Session{}).Where("sessions.id = ?", session.Id).
+		Update("LastSeenAt", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	err = s.issueTokens(uow, &session, tokens)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Revoke will end specified active session of the user, so that its tokens can no longer be used.
+func (s *sessionController) Revoke(sessionId, userId uuid.UUID) error {
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	var totalCount int64
+
+	err := uow.DB.Model(&models.Session{}).
+		Where("sessions.id = ? AND sessions.user_id = ? AND sessions.revoked_at IS NULL", sessionId, userId).
+		Count(&totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	if totalCount == 0 {
+		return errors.New("session not found")
+	}
+
+	err = revokeSessions(uow, userId, revokeLogout, &sessionId)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// RevokeOthers will end all active sessions of the user except specified session, e.g. to log out
+// of a lost device.
+func (s *sessionController) RevokeOthers(sessionId, userId uuid.UUID) error {
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	err := uow.DB.Model(&models.Session{}).
+		Where("sessions.user_id = ? AND sessions.id <> ? AND sessions.revoked_at IS NULL", userId, sessionId).
+		Updates(map[string]interface{}{
+			"RevokedAt":    time.Now(),
+			"RevokeReason": revokeOthers,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// GetSessions will fetch active sessions of specified user, most recently used first. Session of the
+// request is marked as current.
+func (s *sessionController) GetSessions(sessions *[]models.SessionDTO, userId, currentSessionId uuid.UUID) error {
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	// session whose refresh token has expired can no longer be used even though it is not revoked.
+	err := uow.DB.Where("sessions.user_id = ? AND sessions.revoked_at IS NULL", userId).
+		Where("EXISTS (SELECT 1 FROM refresh_tokens WHERE refresh_tokens.session_id = sessions.id"+
+			" AND refresh_tokens.rotated_at IS NULL AND refresh_tokens.expires_on > ?)", time.Now()).
+		Order("sessions.last_seen_at DESC").Find(sessions).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range *sessions {
+		(*sessions)[i].Current = (*sessions)[i].Id == currentSessionId
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// issueTokens will create a new refresh token for the session and a login token which expires in
+// AccessTokenExpiry.
+func (s *sessionController) issueTokens(uow *db.UnitOfWork, session *models.Session, tokens *models.AuthTokens) error {
+	refreshToken, hash, err := security.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Create(&models.RefreshToken{
+		SessionId: session.Id,
+		TokenHash: hash,
+		ExpiresOn: time.Now().Add(models.RefreshTokenExpiry),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	tokens.SessionId = session.Id
+	tokens.RefreshToken = refreshToken
+	tokens.ExpiresOn = time.Now().Add(models.AccessTokenExpiry)
+	tokens.AccessToken, err = security.GenerateJWT(&models.User{Base: models.Base{Id: session.UserId}},
+		session.Id, tokens.ExpiresOn)
+	return err
+}
+
+// revokeSessions will revoke specified active session of the user with the reason. All sessions of
+// the user are revoked if session is not specified.
+func revokeSessions(uow *db.UnitOfWork, userId uuid.UUID, reason string, sessionId *uuid.UUID) error {
+	queryDB := uow.DB.Model(&models.Session{}).
+		Where("sessions.user_id = ? AND sessions.revoked_at IS NULL", userId)
+
+	if sessionId != nil {
+		queryDB = queryDB.Where("sessions.id = ?", *sessionId)
+	}
+
+	return queryDB.Updates(map[string]interface{}{
+		"RevokedAt":    time.Now(),
+		"RevokeReason": reason,
+	}).Error
+}
@@ -0,0 +1,493 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/shaileshhb/equisplit/src/balance"
+	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettlementController will contain all methods to be implemented by settlement controller
+type SettlementController interface {
+	Add(settlement *models.Settlement) error
+	Confirm(userId, settlementId uuid.UUID) error
+	Reject(userId, settlementId uuid.UUID, reason string) error
+	Delete(userId, settlementId uuid.UUID) error
+	SettleMember(uow *db.UnitOfWork, groupId, memberId, userId uuid.UUID) error
+	GetGroupSettlements(settlements *[]models.SettlementDTO, groupId uuid.UUID, totalCount *int64, parser *util.Parser) error
+}
+
+type settlementController struct {
+	db      *gorm.DB
+	fxCon   FxRateController
+	balance balance.Engine
+}
+
+// NewSettlementController will return new instance of SettlementController.
+func NewSettlementController(db *gorm.DB) SettlementController {
+	return &settlementController{
+		db:      db,
+		fxCon:   NewFxRateController(db),
+		balance: balance.NewEngine(db),
+	}
+}
+
+// Add will record a payment from one member to another. Payment recorded by the member who paid is
+// pending until the receiver confirms it, while payment recorded by the receiver, or paid to a
+// placeholder, is confirmed right away.
+func (s *settlementController) Add(settlement *models.Settlement) error {
+	group := models.Group{}
+
+	err := s.db.Where("groups.id = ?", settlement.GroupId).First(&group).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("group not found")
+		}
+		return err
+	}
+
+	err = s.doesUserExistInGroup(settlement.FromUserId, settlement.GroupId)
+	if err != nil {
+		return err
+	}
+
+	err = s.doesUserExistInGroup(settlement.ToUserId, settlement.GroupId)
+	if err != nil {
+		return err
+	}
+
+	if settlement.Date.IsZero() {
+		settlement.Date = time.Now()
+	}
+
+	err = settlement.Amount.SetDefaultCurrency(group.BaseCurrency)
+	if err != nil {
+		return err
+	}
+
+	settlement.BaseAmount, settlement.FxRate, err = s.fxCon.Convert(settlement.Amount, group.BaseCurrency, settlement.Date)
+	if err != nil {
+		return err
+	}
+
+	settlement.TransactionIds = lo.Uniq(settlement.TransactionIds)
+	settlement.Transactions = make([]models.GroupTransaction, len(settlement.TransactionIds))
+	for index, transactionId := range settlement.TransactionIds {
+		settlement.Transactions[index].Id = transactionId
+	}
+
+	settlement.Status = models.SettlementPendingConfirmation
+
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	// pending transactions are checked now so that a claim which can never be confirmed is not
+	// recorded. They are checked again with a lock when the settlement is confirmed.
+	_, err = s.getPendingTransactions(uow, settlement, false)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Omit("Transactions.*", "Allocations").Create(settlement).Error
+	if err != nil {
+		return err
+	}
+
+	// placeholder cannot confirm the payment, so payment to a placeholder is confirmed right away.
+	toUser := models.User{}
+
+	err = uow.DB.Where("users.id = ?", settlement.ToUserId).First(&toUser).Error
+	if err != nil {
+		return err
+	}
+
+	if settlement.CreatedBy == settlement.ToUserId || toUser.IsPlaceholder {
+		err = s.confirm(uow, settlement, settlement.CreatedBy)
+		if err != nil {
+			return err
+		}
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Confirm will confirm specified pending settlement, which covers the transactions of the pair.
+// Only the member who received the payment can confirm it.
+func (s *settlementController) Confirm(userId, settlementId uuid.UUID) error {
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	settlement, err := s.getPendingSettlement(uow, userId, settlementId)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Table("settlement_transactions").Where("settlement_transactions.settlement_id = ?", settlement.Id).
+		Pluck("settlement_transactions.group_transaction_id", &settlement.TransactionIds).Error
+	if err != nil {
+		return err
+	}
+
+	err = s.confirm(uow, &settlement, userId)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// Reject will reject specified pending settlement with the reason. Balances are not changed.
+// Only the member who was supposed to receive the payment can reject it.
+func (s *settlementController) Reject(userId, settlementId uuid.UUID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason must be specified")
+	}
+
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	settlement, err := s.getPendingSettlement(uow, userId, settlementId)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.Settlement{}).Where("settlements.id = ?", settlement.Id).
+		Updates(map[string]interface{}{
+			"Status":          models.SettlementRejected,
+			"RespondedBy":     userId,
+			"RespondedAt":     time.Now(),
+			"RejectionReason": reason,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// getPendingSettlement will lock and fetch specified settlement if it is pending and userId is its receiver.
+func (s *settlementController) getPendingSettlement(uow *db.UnitOfWork, userId,
+	settlementId uuid.UUID) (models.Settlement, error) {
+
+	settlement := models.Settlement{}
+
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("settlements.id = ?", settlementId).First(&settlement).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return settlement, errors.New("settlement not found")
+		}
+		return settlement, err
+	}
+
+	if settlement.ToUserId != userId {
+		return settlement, errors.New("only receiver can respond to a settlement")
+	}
+
+	if settlement.Status != models.SettlementPendingConfirmation {
+		return settlement, errors.New("settlement is already " + string(settlement.Status))
+	}
+
+	return settlement, nil
+}
+
+// confirm will allocate the settlement to the pending transactions of the pair, mark it as
+// confirmed by userId and update balances of the pair.
+func (s *settlementController) confirm(uow *db.UnitOfWork, settlement *models.Settlement, userId uuid.UUID) error {
+	err := s.allocate(uow, settlement)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Create(&settlement.Allocations).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	settlement.Status = models.SettlementConfirmed
+	settlement.RespondedBy = &userId
+	settlement.RespondedAt = &now
+
+	err = uow.DB.Model(&models.Settlement{}).Where("settlements.id = ?", settlement.Id).
+		Updates(map[string]interface{}{
+			"Status":      settlement.Status,
+			"RespondedBy": settlement.RespondedBy,
+			"RespondedAt": settlement.RespondedAt,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = s.balance.Refresh(uow, settlement.GroupId, settlement.FromUserId, settlement.ToUserId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getPendingTransactions will fetch the pending transactions in which FromUser owes ToUser, oldest
+// first, restricted to TransactionIds if specified. It returns an error if they do not cover base
+// amount of the settlement. Transactions are locked if lock is true.
+func (s *settlementController) getPendingTransactions(uow *db.UnitOfWork, settlement *models.Settlement,
+	lock bool) ([]models.GroupTransaction, error) {
+
+	transactions := []models.GroupTransaction{}
+
+	queryDB := uow.DB
+	if lock {
+		queryDB = queryDB.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	queryDB = queryDB.Where("group_transactions.group_id = ? AND group_transactions.payer_id = ? AND group_transactions.payee_id = ?",
+		settlement.GroupId, settlement.ToUserId, settlement.FromUserId).
+		Where("group_transactions.is_paid = ? AND group_transactions.is_adjusted = ?", false, false)
+
+	if len(settlement.TransactionIds) > 0 {
+		queryDB = queryDB.Where("group_transactions.id IN (?)", settlement.TransactionIds)
+	}
+
+	err := queryDB.Order("group_transactions.date, group_transactions.created_at").Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settlement.TransactionIds) > 0 && len(transactions) != len(settlement.TransactionIds) {
+		return nil, errors.New("transaction not found or already paid")
+	}
+
+	if len(transactions) == 0 {
+		return nil, errors.New("no pending transactions found")
+	}
+
+	var outstanding int64
+	for index := range transactions {
+		outstanding += transactions[index].Outstanding().Minor
+	}
+
+	if settlement.BaseAmount.Minor > outstanding {
+		return nil, errors.New("amount is more than the outstanding amount")
+	}
+
+	return transactions, nil
+}
+
+// allocate will cover the pending transactions in which FromUser owes ToUser with base amount of
+// the settlement and add an allocation for every transaction covered. Transactions are locked so
+// that concurrent settlements of the same pair cannot cover the same amount twice.
+func (s *settlementController) allocate(uow *db.UnitOfWork, settlement *models.Settlement) error {
+	transactions, err := s.getPendingTransactions(uow, settlement, true)
+	if err != nil {
+		return err
+	}
+
+	remaining := settlement.BaseAmount.Minor
+	settlement.Allocations = []models.SettlementAllocation{}
+
+	for _, t := range transactions {
+		if remaining == 0 {
+			break
+		}
+
+		amount := min(remaining, t.Outstanding().Minor)
+		remaining -= amount
+
+		paidAmount := t.PaidAmount.Minor + amount
+
+		err = uow.DB.Model(&models.GroupTransaction{}).Where("group_transactions.id = ?", t.Id).
+			Updates(map[string]interface{}{
+				"paid_amount_minor":    paidAmount,
+				"paid_amount_currency": t.BaseAmount.Currency,
+				"IsPaid":               paidAmount >= t.BaseAmount.Minor,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		settlement.Allocations = append(settlement.Allocations, models.SettlementAllocation{
+			SettlementId:       settlement.Id,
+			GroupTransactionId: t.Id,
+			Amount:             models.NewMoney(amount, settlement.BaseAmount.Currency),
+		})
+	}
+
+	return nil
+}
+
+// pairAmount is the outstanding amount PayeeId owes PayerId.
+type pairAmount struct {
+	PayerId uuid.UUID
+	PayeeId uuid.UUID
+	Amount  int64
+}
+
+// SettleMember will record a confirmed settlement for every member with whom specified member has
+// pending transactions, covering the whole outstanding amount of the pair. It is used when the member
+// leaves the group and the balance is settled outside the app, so settlements are confirmed by userId.
+func (s *settlementController) SettleMember(uow *db.UnitOfWork, groupId, memberId, userId uuid.UUID) error {
+	group := models.Group{}
+
+	err := uow.DB.Where("groups.id = ?", groupId).First(&group).Error
+	if err != nil {
+		return err
+	}
+
+	pairs := []pairAmount{}
+
+	err = uow.DB.Model(&models.GroupTransaction{}).
+		Select("payer_id, payee_id, SUM(base_amount_minor - paid_amount_minor) AS amount").
+		Where("group_transactions.group_id = ? AND (group_transactions.payer_id = ? OR group_transactions.payee_id = ?)",
+			groupId, memberId, memberId).
+		Where("group_transactions.is_paid = ? AND group_transactions.is_adjusted = ?", false, false).
+		Group("payer_id, payee_id").Scan(&pairs).Error
+	if err != nil {
+		return err
+	}
+
+	note := "settled when member left the group"
+
+	for _, pair := range pairs {
+		if pair.Amount <= 0 || pair.PayerId == pair.PayeeId {
+			continue
+		}
+
+		amount := models.NewMoney(pair.Amount, group.BaseCurrency)
+
+		settlement := models.Settlement{
+			GroupId:    groupId,
+			FromUserId: pair.PayeeId,
+			ToUserId:   pair.PayerId,
+			CreatedBy:  userId,
+			Amount:     amount,
+			BaseAmount: amount,
+			FxRate:     1,
+			Method:     models.SettlementOther,
+			Date:       time.Now(),
+			Note:       &note,
+			Status:     models.SettlementPendingConfirmation,
+		}
+
+		err = uow.DB.Omit("Transactions.*", "Allocations").Create(&settlement).Error
+		if err != nil {
+			return err
+		}
+
+		err = s.confirm(uow, &settlement, userId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete will delete specified settlement. Only pending settlement can be deleted by its creator,
+// so that confirmed and rejected settlements remain in the history of both members.
+func (s *settlementController) Delete(userId, settlementId uuid.UUID) error {
+	settlement := models.Settlement{}
+
+	err := s.db.Where("settlements.id = ?", settlementId).First(&settlement).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("settlement not found")
+		}
+		return err
+	}
+
+	if settlement.CreatedBy != userId {
+		return errors.New("only creator can delete a settlement")
+	}
+
+	if settlement.Status != models.SettlementPendingConfirmation {
+		return errors.New("only pending settlement can be deleted")
+	}
+
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Delete(&models.Settlement{}, settlement.Id).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// GetGroupSettlements will fetch all settlements of specified group, including pending and rejected ones.
+func (s *settlementController) GetGroupSettlements(settlements *[]models.SettlementDTO, groupId uuid.UUID,
+	totalCount *int64, parser *util.Parser) error {
+
+	err := s.doesGroupExist(groupId)
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(s.db)
+	defer uow.RollBack()
+
+	whereDB := uow.DB.Model(&models.Settlement{}).Where("settlements.group_id = ?", groupId)
+
+	if len(parser.GetQuery("status")) > 0 {
+		whereDB = whereDB.Where("settlements.status = ?", parser.GetQuery("status"))
+	}
+
+	if len(parser.GetQuery("userId")) > 0 {
+		whereDB = whereDB.Where("settlements.from_user_id = ? OR settlements.to_user_id = ?",
+			parser.GetQuery("userId"), parser.GetQuery("userId"))
+	}
+
+	err = whereDB.Count(totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	limit, offset := parser.ParseLimitAndOffset()
+
+	err = whereDB.Limit(limit).Offset(offset).Preload("FromUser").Preload("ToUser").Preload("Allocations").
+		Order("settlements.date DESC").Find(settlements).Error
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// doesGroupExist will check if specified group exist or not.
+func (s *settlementController) doesGroupExist(groupId uuid.UUID) error {
+	err := s.db.Where("groups.id = ?", groupId).First(&models.Group{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("group not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// doesUserExistInGroup will check if specified user exist in group or not.
+func (s *settlementController) doesUserExistInGroup(userId, groupId uuid.UUID) error {
+	err := s.db.Where("user_groups.user_id = ? AND user_groups.group_id = ?", userId, groupId).
+		First(&models.UserGroup{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found in this group")
+		}
+		return err
+	}
+	return nil
+}
@@ -5,32 +5,55 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/balance"
 	"github.com/shaileshhb/equisplit/src/db"
 	"github.com/shaileshhb/equisplit/src/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserGroupController interface {
-	AddUserToGroup(userGroup *models.UserGroup) error
-	DeleteUserFromGroup(userGroup *models.UserGroup) error
+	AddUserToGroup(userGroup *models.UserGroup, userId uuid.UUID) error
+	DeleteUserFromGroup(userGroup *models.UserGroup, userId uuid.UUID, options *models.DepartureOptions) error
+	UpdateRole(userGroup *models.UserGroup, userId uuid.UUID) error
+	TransferOwnership(groupId, successorId, userId uuid.UUID) error
 	GetGroupDetails(userGroups *[]models.UserGroupDTO, groupId, userId uuid.UUID) error
 	GetUserGroups(userGroups *[]models.UserGroupDTO, userId uuid.UUID) error
 	GetGroupUsers(users *[]models.UserGroupDTO, groupId uuid.UUID) error
 }
 
 type userGroupController struct {
-	db *gorm.DB
+	db            *gorm.DB
+	settlementCon SettlementController
+	balance       balance.Engine
 }
 
 func NewUserGroupController(db *gorm.DB) UserGroupController {
 	return &userGroupController{
-		db: db,
+		db:            db,
+		settlementCon: NewSettlementController(db),
+		balance:       balance.NewEngine(db),
 	}
 }
 
-// AddUserToGroup will add specified user to the group.
-func (u *userGroupController) AddUserToGroup(userGroup *models.UserGroup) error {
-	err := u.doesUserExist(userGroup.UserId)
+// AddUserToGroup will add specified user to the group as a member.
+func (u *userGroupController) AddUserToGroup(userGroup *models.UserGroup, userId uuid.UUID) error {
+	err := doesRegisteredUserExist(u.db, userGroup.UserId)
+	if err != nil {
+		return err
+	}
+
+	err = checkEmailVerified(u.db, userGroup.UserId)
+	if err != nil {
+		return err
+	}
+
+	err = checkPermission(u.db, userId, userGroup.GroupId, models.PermissionInvite)
+	if err != nil {
+		return err
+	}
+
+	err = checkEmailVerified(u.db, userId)
 	if err != nil {
 		return err
 	}
@@ -48,20 +71,116 @@ func (u *userGroupController) AddUserToGroup(userGroup *models.UserGroup) error
 	uow := db.NewUnitOfWork(u.db)
 	defer uow.RollBack()
 
-	var totalCount int64 = 0
+	err = checkMemberLimit(uow, userGroup.GroupId)
+	if err != nil {
+		return err
+	}
+
+	err = addMember(uow, userGroup.UserId, userGroup.GroupId, models.RoleMember)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// DeleteUserFromGroup will delete specified user from the group. Any member can leave the group, but
+// removing others is allowed only for roles which have the permission. Owner cannot be removed by
+// others and when the owner leaves, ownership is transferred to successor if specified or else to
+// the longest-standing admin.
+//
+// A member who has outstanding balance cannot leave until it is resolved by settling it, reassigning
+// it to another member or writing it off. Resolving the balance affects other members, so only roles
+// which have the permission can specify the resolution. Every departure is recorded in member_departures.
+func (u *userGroupController) DeleteUserFromGroup(userGroup *models.UserGroup, userId uuid.UUID, options *models.DepartureOptions) error {
+
+	err := options.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = u.doesUserGroupExist(userGroup.Id)
+	if err != nil {
+		return err
+	}
+
+	err = u.db.Where("user_groups.id = ?", userGroup.Id).First(userGroup).Error
+	if err != nil {
+		return err
+	}
+
+	if userGroup.UserId != userId {
+		if userGroup.Role == models.RoleOwner {
+			return errors.New("owner cannot be removed from the group")
+		}
+
+		err = u.checkRoleChange(userGroup, userId, models.PermissionRemoveMember)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Resolution != models.ResolutionNone {
+		err = checkPermission(u.db, userId, userGroup.GroupId, models.PermissionResolveBalances)
+		if err != nil {
+			return err
+		}
+	}
+
+	uow := db.NewUnitOfWork(u.db)
+	defer uow.RollBack()
+
+	// refreshing the balance locks the group and the member, so the balance cannot change until the
+	// member has left.
+	err = u.balance.Refresh(uow, userGroup.GroupId, userGroup.UserId)
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Where("user_groups.id = ?", userGroup.Id).First(userGroup).Error
+	if err != nil {
+		return err
+	}
+
+	hasBalance := !userGroup.IncomingAmount.IsZero() || !userGroup.OutgoingAmount.IsZero()
+
+	if hasBalance && options.Resolution == models.ResolutionNone {
+		return errors.New("member has outstanding balance, it must be settled, reassigned or written off")
+	}
+
+	if !hasBalance && options.Resolution != models.ResolutionNone {
+		return errors.New("member does not have any outstanding balance")
+	}
+
+	if userGroup.Role == models.RoleOwner {
+		err = u.handOverOwnership(uow, userGroup, options.SuccessorId)
+		if err != nil {
+			return err
+		}
+	}
 
-	err = uow.DB.Model(&models.UserGroup{}).Where("user_groups.group_id = ?", userGroup.GroupId).Count(&totalCount).Error
+	err = u.resolveBalance(uow, userGroup, userId, options)
 	if err != nil {
 		return err
 	}
 
-	if totalCount >= 10 {
-		return errors.New("maximum number of people already added to the group")
+	err = uow.DB.Model(&models.UserGroup{}).Where("user_groups.id = ?", userGroup.Id).
+		Updates(map[string]interface{}{
+			"DeletedAt": time.Now(),
+		}).Error
+	if err != nil {
+		return err
 	}
 
-	err = uow.DB.Create(&models.UserGroup{
-		UserId:  userGroup.UserId,
-		GroupId: userGroup.GroupId,
+	err = uow.DB.Create(&models.MemberDeparture{
+		GroupId:        userGroup.GroupId,
+		UserId:         userGroup.UserId,
+		ResolvedBy:     userId,
+		Resolution:     options.Resolution,
+		ReassignedTo:   options.ReassignTo,
+		IncomingAmount: userGroup.IncomingAmount,
+		OutgoingAmount: userGroup.OutgoingAmount,
 	}).Error
 	if err != nil {
 		return err
@@ -71,10 +190,156 @@ func (u *userGroupController) AddUserToGroup(userGroup *models.UserGroup) error
 	return nil
 }
 
-// DeleteUserFromGroup will delete specified user from the group.
-func (u *userGroupController) DeleteUserFromGroup(userGroup *models.UserGroup) error {
+// resolveBalance will resolve outstanding balance of the member who is leaving the group as
+// specified in options and update balances of all members of the group.
+func (u *userGroupController) resolveBalance(uow *db.UnitOfWork, userGroup *models.UserGroup, userId uuid.UUID,
+	options *models.DepartureOptions) error {
+
+	var err error
+
+	switch options.Resolution {
+	case models.ResolutionNone:
+		return nil
+
+	case models.ResolutionSettle:
+		err = u.settlementCon.SettleMember(uow, userGroup.GroupId, userGroup.UserId, userId)
+
+	case models.ResolutionReassign:
+		err = u.reassignBalance(uow, userGroup, *options.ReassignTo)
+
+	case models.ResolutionWriteOff:
+		err = uow.DB.Model(&models.GroupTransaction{}).
+			Where("group_transactions.group_id = ? AND group_transactions.is_paid = ? AND group_transactions.is_adjusted = ?",
+				userGroup.GroupId, false, false).
+			Where("(group_transactions.payer_id = ? OR group_transactions.payee_id = ?)", userGroup.UserId, userGroup.UserId).
+			Update("IsAdjusted", true).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return u.balance.Refresh(uow, userGroup.GroupId)
+}
+
+// reassignBalance will move pending transactions of the member to specified member of the group.
+// Transactions between the two members become transactions of the member with themselves, so they
+// are marked as adjusted.
+func (u *userGroupController) reassignBalance(uow *db.UnitOfWork, userGroup *models.UserGroup, reassignTo uuid.UUID) error {
+	if reassignTo == userGroup.UserId {
+		return errors.New("balance cannot be reassigned to the member who is leaving")
+	}
+
+	err := uow.DB.Where("user_groups.user_id = ? AND user_groups.group_id = ?", reassignTo, userGroup.GroupId).
+		First(&models.UserGroup{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("member to reassign the balance to not found in this group")
+		}
+		return err
+	}
+
+	pendingDB := func() *gorm.DB {
+		return uow.DB.Model(&models.GroupTransaction{}).
+			Where("group_transactions.group_id = ? AND group_transactions.is_paid = ? AND group_transactions.is_adjusted = ?",
+				userGroup.GroupId, false, false)
+	}
+
+	err = pendingDB().Where("group_transactions.payer_id = ?", userGroup.UserId).Update("PayerId", reassignTo).Error
+	if err != nil {
+		return err
+	}
+
+	err = pendingDB().Where("group_transactions.payee_id = ?", userGroup.UserId).Update("PayeeId", reassignTo).Error
+	if err != nil {
+		return err
+	}
+
+	return pendingDB().Where("group_transactions.payer_id = ? AND group_transactions.payee_id = ?", reassignTo, reassignTo).
+		Update("IsAdjusted", true).Error
+}
+
+// handOverOwnership will transfer ownership of the group before the owner leaves it. If successor
+// is not specified the longest-standing admin becomes the owner.
+func (u *userGroupController) handOverOwnership(uow *db.UnitOfWork, owner *models.UserGroup, successorId *uuid.UUID) error {
+	if successorId != nil {
+		err := u.doesSuccessorExist(*successorId, owner.GroupId)
+		if err != nil {
+			return err
+		}
+
+		if *successorId == owner.UserId {
+			return errors.New("successor must be another member of the group")
+		}
+
+		return transferOwnership(uow, owner.GroupId, owner.UserId, *successorId)
+	}
+
+	successor, err := getLongestStandingMember(uow, owner.GroupId, owner.UserId, models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	if successor == nil {
+		return errors.New("successor must be specified as there are no admins in the group")
+	}
+
+	return transferOwnership(uow, owner.GroupId, owner.UserId, successor.UserId)
+}
+
+// TransferOwnership will make specified member the owner of the group. Only owner can transfer
+// ownership and the previous owner is made an admin.
+func (u *userGroupController) TransferOwnership(groupId, successorId, userId uuid.UUID) error {
+	err := u.doesGroupExist(groupId)
+	if err != nil {
+		return err
+	}
+
+	err = u.doesSuccessorExist(successorId, groupId)
+	if err != nil {
+		return err
+	}
+
+	if successorId == userId {
+		return errors.New("successor must be another member of the group")
+	}
+
+	uow := db.NewUnitOfWork(u.db)
+	defer uow.RollBack()
+
+	owner := models.UserGroup{}
+
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_groups.group_id = ? AND user_groups.user_id = ?", groupId, userId).First(&owner).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found in this group")
+		}
+		return err
+	}
+
+	if owner.Role != models.RoleOwner {
+		return errors.New("only owner can transfer ownership")
+	}
+
+	err = transferOwnership(uow, groupId, userId, successorId)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// UpdateRole will change role of specified member of the group. Ownership is transferred by making
+// another member the owner, which makes the previous owner an admin. Only owner can transfer
+// ownership or change role of an admin.
+func (u *userGroupController) UpdateRole(userGroup *models.UserGroup, userId uuid.UUID) error {
+	err := userGroup.Role.Validate()
+	if err != nil {
+		return err
+	}
 
-	err := u.doesUserGroupExist(userGroup.Id)
+	err = u.doesUserGroupExist(userGroup.Id)
 	if err != nil {
 		return err
 	}
@@ -82,10 +347,44 @@ func (u *userGroupController) DeleteUserFromGroup(userGroup *models.UserGroup) e
 	uow := db.NewUnitOfWork(u.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Model(&models.UserGroup{}).Where("user_groups.user_id = ? AND user_groups.group_id = ?", userGroup.UserId, userGroup.GroupId).
-		Updates(map[string]interface{}{
-			"DeletedAt": time.Now(),
-		}).Error
+	tempUserGroup := models.UserGroup{}
+
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_groups.id = ?", userGroup.Id).First(&tempUserGroup).Error
+	if err != nil {
+		return err
+	}
+
+	if tempUserGroup.Role == models.RoleOwner {
+		return errors.New("role of owner can only be changed by transferring ownership")
+	}
+
+	err = u.checkRoleChange(&tempUserGroup, userId, models.PermissionManageRoles)
+	if err != nil {
+		return err
+	}
+
+	if userGroup.Role == models.RoleOwner {
+		role, err := getMemberRole(uow.DB, userId, tempUserGroup.GroupId)
+		if err != nil {
+			return err
+		}
+
+		if role != models.RoleOwner {
+			return errors.New("only owner can transfer ownership")
+		}
+
+		err = transferOwnership(uow, tempUserGroup.GroupId, userId, tempUserGroup.UserId)
+		if err != nil {
+			return err
+		}
+
+		uow.Commit()
+		return nil
+	}
+
+	err = uow.DB.Model(&models.UserGroup{}).Where("user_groups.id = ?", tempUserGroup.Id).
+		Update("Role", userGroup.Role).Error
 	if err != nil {
 		return err
 	}
@@ -94,6 +393,30 @@ func (u *userGroupController) DeleteUserFromGroup(userGroup *models.UserGroup) e
 	return nil
 }
 
+// checkRoleChange will check if specified user has the permission to act on the member. Admins
+// can only be acted upon by the owner.
+func (u *userGroupController) checkRoleChange(userGroup *models.UserGroup, userId uuid.UUID,
+	permission models.GroupPermission) error {
+
+	err := checkPermission(u.db, userId, userGroup.GroupId, permission)
+	if err != nil {
+		return err
+	}
+
+	if userGroup.Role == models.RoleAdmin {
+		role, err := getMemberRole(u.db, userId, userGroup.GroupId)
+		if err != nil {
+			return err
+		}
+
+		if role != models.RoleOwner {
+			return errors.New("only owner can " + string(permission) + " who are admin")
+		}
+	}
+
+	return nil
+}
+
 // GetGroupDetails will fetch all user details of specified group.
 func (u *userGroupController) GetGroupDetails(userGroups *[]models.UserGroupDTO, groupId, userId uuid.UUID) error {
 
@@ -105,31 +428,46 @@ func (u *userGroupController) GetGroupDetails(userGroups *[]models.UserGroupDTO,
 	uow := db.NewUnitOfWork(u.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Where("user_groups.group_id = ?", groupId).
+	group := models.Group{}
+	err = uow.DB.Where("groups.id = ?", groupId).First(&group).Error
+	if err != nil {
+		return err
+	}
+
+	// members who left are included so that their past expenses and transactions can be shown.
+	err = uow.DB.Unscoped().Where("user_groups.group_id = ?", groupId).
 		Preload("User").Find(userGroups).Error
 	if err != nil {
 		return err
 	}
 
+	setMemberStatus(*userGroups)
+
+	// amounts of the summary are in base currency of the group. Pending transactions are summed with the
+	// same filters as the balance engine, and deleted transactions are excluded by the model.
 	for index := range *userGroups {
 		(*userGroups)[index].Summary = &models.GroupSummary{
-			UserId: (*userGroups)[index].UserId,
+			UserId:         (*userGroups)[index].UserId,
+			OutgoingAmount: models.NewMoney(0, group.BaseCurrency),
+			IncomingAmount: models.NewMoney(0, group.BaseCurrency),
 		}
 		if userId == (*userGroups)[index].UserId {
 			continue
 		}
 
-		err = uow.DB.Select("SUM(amount) AS incoming_amount").Table("group_transactions").
-			Where("group_id = ? AND payer_id = ? AND payee_id = ? AND is_paid = ?",
-				(*userGroups)[index].GroupId, (*userGroups)[index].UserId, userId, false).
+		err = uow.DB.Model(&models.GroupTransaction{}).
+			Select("SUM(base_amount_minor - paid_amount_minor) AS incoming_amount_minor").
+			Where("group_id = ? AND payer_id = ? AND payee_id = ? AND is_paid = ? AND is_adjusted = ?",
+				(*userGroups)[index].GroupId, (*userGroups)[index].UserId, userId, false, false).
 			Scan(&(*userGroups)[index].Summary).Error
 		if err != nil {
 			return err
 		}
 
-		err = uow.DB.Select("SUM(amount) AS outgoing_amount").Table("group_transactions").
-			Where("group_id = ? AND payee_id = ? AND payer_id = ? AND is_paid = ?",
-				(*userGroups)[index].GroupId, (*userGroups)[index].UserId, userId, false).
+		err = uow.DB.Model(&models.GroupTransaction{}).
+			Select("SUM(base_amount_minor - paid_amount_minor) AS outgoing_amount_minor").
+			Where("group_id = ? AND payee_id = ? AND payer_id = ? AND is_paid = ? AND is_adjusted = ?",
+				(*userGroups)[index].GroupId, (*userGroups)[index].UserId, userId, false, false).
 			Scan(&(*userGroups)[index].Summary).Error
 		if err != nil {
 			return err
@@ -156,21 +494,12 @@ func (u *userGroupController) GetUserGroups(userGroups *[]models.UserGroupDTO, u
 		return err
 	}
 
+	// amounts of the user are maintained in user_groups by the balance engine.
 	for index := range *userGroups {
-		err = uow.DB.Select("SUM(amount) AS outgoing_amount").Table("group_transactions").
-			Where("group_transactions.group_id = ? AND group_transactions.payer_id = ? AND is_paid = ?",
-				(*userGroups)[index].GroupId, userId, false).
-			Scan(&(*userGroups)[index].Summary).Error
-		if err != nil {
-			return err
-		}
-
-		err = uow.DB.Select("SUM(amount) AS incoming_amount").Table("group_transactions").
-			Where("group_transactions.group_id = ? AND group_transactions.payee_id = ? AND is_paid = ?",
-				(*userGroups)[index].GroupId, userId, false).
-			Scan(&(*userGroups)[index].Summary).Error
-		if err != nil {
-			return err
+		(*userGroups)[index].Summary = &models.GroupSummary{
+			UserId:         userId,
+			OutgoingAmount: (*userGroups)[index].OutgoingAmount,
+			IncomingAmount: (*userGroups)[index].IncomingAmount,
 		}
 	}
 
@@ -189,15 +518,27 @@ func (u *userGroupController) GetGroupUsers(users *[]models.UserGroupDTO, groupI
 	uow := db.NewUnitOfWork(u.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Where("group_id = ?", groupId).Preload("User").Find(users).Error
+	err = uow.DB.Unscoped().Where("group_id = ?", groupId).Preload("User").Find(users).Error
 	if err != nil {
 		return err
 	}
 
+	setMemberStatus(*users)
+
 	uow.Commit()
 	return nil
 }
 
+// setMemberStatus will set status of the members, members who left the group are soft deleted.
+func setMemberStatus(userGroups []models.UserGroupDTO) {
+	for index := range userGroups {
+		userGroups[index].Status = models.MemberActive
+		if userGroups[index].DeletedAt.Valid {
+			userGroups[index].Status = models.MemberLeft
+		}
+	}
+}
+
 // doesUserExist will check if specified user exist or not.
 func (u *userGroupController) doesUserExist(userId uuid.UUID) error {
 	err := u.db.Where("users.id = ?", userId).First(&models.User{}).Error
@@ -234,6 +575,18 @@ func (u *userGroupController) doesUserExistInGroup(userId, groupId uuid.UUID) er
 	return errors.New("user already exists in specified group")
 }
 
+// doesSuccessorExist will check if specified successor is a member of the group.
+func (u *userGroupController) doesSuccessorExist(userId, groupId uuid.UUID) error {
+	err := u.db.Where("user_groups.user_id = ? AND user_groups.group_id = ?", userId, groupId).First(&models.UserGroup{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("successor not found in this group")
+		}
+		return err
+	}
+	return nil
+}
+
 // doesUserGroupExist will check if specified user_group exist or not.
 func (u *userGroupController) doesUserGroupExist(userGroupId uuid.UUID) error {
 	err := u.db.Where("user_groups.id = ?", userGroupId).First(&models.UserGroup{}).Error
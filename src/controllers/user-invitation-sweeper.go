@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// StartInvitationSweeper will mark pending invitations whose expiry has passed as expired every
+// interval in the background. Expiry is also checked when an invitation is accepted, so the sweeper
+// only keeps the stored status up to date.
+func StartInvitationSweeper(con UserInvitationController, interval time.Duration, log zerolog.Logger) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			var totalCount int64
+			err := con.ExpireInvitations(&totalCount)
+			if err != nil {
+				log.Error().Err(err).Msg("Error expiring invitations")
+				continue
+			}
+			if totalCount > 0 {
+				log.Info().Int64("count", totalCount).Msg("Invitations expired")
+			}
+		}
+	}()
+}
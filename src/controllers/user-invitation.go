@@ -6,110 +6,250 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/mail"
 	"github.com/shaileshhb/equisplit/src/models"
 	"github.com/shaileshhb/equisplit/src/util"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserInvitationController interface {
 	Add(invitation *models.UserInvitation) error
-	AcceptInvitation(invitation *models.UserInvitation) error
-	DeleteInvitation(invitation *models.UserInvitation) error
+	AcceptInvitation(invitationId, userId uuid.UUID) error
+	DeclineInvitation(invitationId, userId uuid.UUID) error
+	RevokeInvitation(invitationId, userId uuid.UUID) error
+	ResendInvitation(invitationId, userId uuid.UUID) error
+	ExpireInvitations(totalCount *int64) error
 	GetInvitations(invitations *[]models.UserInvitationDTO, parser *util.Parser) error
-	GetGroupInvitation(invitations *[]models.UserInvitation, groupId uuid.UUID) error
+	GetGroupInvitation(invitations *[]models.UserInvitationDTO, groupId uuid.UUID) error
 }
 
 type userInvitationController struct {
-	db *gorm.DB
+	db             *gorm.DB
+	mailer         mail.Sender
+	placeholderCon PlaceholderController
 }
 
-func NewUserInvitationController(db *gorm.DB) UserInvitationController {
+func NewUserInvitationController(db *gorm.DB, mailer mail.Sender) UserInvitationController {
 	return &userInvitationController{
-		db: db,
+		db:             db,
+		mailer:         mailer,
+		placeholderCon: NewPlaceholderController(db),
 	}
 }
 
-// Add will add invitation for the specified user in the group.
+// Add will add invitation for the specified user or email in the group. Invitation for an email of a
+// verified user is added for that user, otherwise it is stored until someone verifies the email.
+// Invite email is sent to the invited person.
 func (ui *userInvitationController) Add(invitation *models.UserInvitation) error {
 
-	err := ui.doesUserExist(invitation.UserId)
+	err := checkEmailVerified(ui.db, *invitation.InvitedBy)
 	if err != nil {
 		return err
 	}
 
+	if invitation.Email != nil {
+		err = ui.linkRegisteredUser(invitation)
+		if err != nil {
+			return err
+		}
+	}
+
+	if invitation.UserId != nil {
+		err = doesRegisteredUserExist(ui.db, *invitation.UserId)
+		if err != nil {
+			return err
+		}
+
+		err = checkEmailVerified(ui.db, *invitation.UserId)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = ui.doesGroupExist(invitation.GroupId)
 	if err != nil {
 		return err
 	}
 
-	err = ui.doesUserGroupExist(invitation.UserId, invitation.GroupId)
+	if invitation.UserId != nil {
+		err = ui.doesUserGroupExist(*invitation.UserId, invitation.GroupId)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = checkPermission(ui.db, *invitation.InvitedBy, invitation.GroupId, models.PermissionInvite)
 	if err != nil {
 		return err
 	}
 
+	if invitation.PlaceholderId != nil {
+		err = ui.doesPlaceholderExist(*invitation.PlaceholderId, invitation.GroupId)
+		if err != nil {
+			return err
+		}
+	}
+
 	uow := db.NewUnitOfWork(ui.db)
 	defer uow.RollBack()
 
-	tempInvitation := models.UserInvitation{}
-
-	err = uow.DB.Table("user_invitations").
-		Where("group_id = ? AND user_id = ? AND expires_on > NOW() AND is_accepted = ?",
-			invitation.GroupId, invitation.UserId, false).
-		First(&tempInvitation).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
+	err = ui.doesPendingInvitationExist(uow, invitation)
+	if err != nil {
 		return err
 	}
 
-	if tempInvitation.Id != uuid.Nil {
-		return errors.New("user already invited")
-	}
-
-	expiry := time.Now().Local().AddDate(0, 0, 30)
+	expiry := time.Now().Add(models.InvitationExpiry)
 
 	invitation.ExpiresOn = &expiry
+	invitation.Status = models.InvitationPending
+	invitation.RespondedAt = nil
 
 	err = uow.DB.Create(invitation).Error
 	if err != nil {
 		return err
 	}
 
+	// invitation is not added if the email could not be queued, so that it can be retried.
+	err = ui.sendInvitation(uow, invitation)
+	if err != nil {
+		return err
+	}
+
 	uow.Commit()
 	return nil
 }
 
-// AcceptInvitation will mark invitation as accepted and add user in the group that they were invited to.
-func (ui *userInvitationController) AcceptInvitation(invitation *models.UserInvitation) error {
+// linkRegisteredUser will set the verified user with email of the invitation as the invited user.
+func (ui *userInvitationController) linkRegisteredUser(invitation *models.UserInvitation) error {
+	users := []models.User{}
 
-	err := ui.doesUserInvitationExist(invitation.Id)
+	err := ui.db.Where("LOWER(users.email) = ? AND users.is_placeholder = ? AND users.email_verified = ?",
+		*invitation.Email, false, true).
+		Limit(1).Find(&users).Error
 	if err != nil {
 		return err
 	}
 
-	err = ui.doesGroupExist(invitation.GroupId)
+	if len(users) > 0 {
+		invitation.UserId = &users[0].Id
+		invitation.Email = nil
+	}
+
+	return nil
+}
+
+// sendInvitation will email specified invitation to the invited person. Person invited by email is
+// asked to register with that email.
+func (ui *userInvitationController) sendInvitation(uow *db.UnitOfWork, invitation *models.UserInvitation) error {
+	group := models.Group{}
+
+	err := uow.DB.Where("groups.id = ?", invitation.GroupId).First(&group).Error
 	if err != nil {
 		return err
 	}
 
-	err = ui.doesUserExist(invitation.UserId)
+	inviter := models.User{}
+
+	err = uow.DB.Where("users.id = ?", invitation.InvitedBy).First(&inviter).Error
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Inviter":   inviter.Name,
+		"Group":     group.Name,
+		"ExpiresOn": invitation.ExpiresOn.Format("2 Jan 2006"),
+	}
+
+	if invitation.UserId == nil {
+		data["Email"] = *invitation.Email
+		return ui.send(*invitation.Email, data)
+	}
+
+	user := models.User{}
+
+	err = uow.DB.Where("users.id = ?", invitation.UserId).First(&user).Error
+	if err != nil {
+		return err
+	}
+
+	return ui.send(user.Email, data)
+}
+
+// send will render the invitation email for specified recipient and send it.
+func (ui *userInvitationController) send(to string, data map[string]interface{}) error {
+	message, err := mail.Render(to, "invitation", data)
 	if err != nil {
 		return err
 	}
 
+	return ui.mailer.Send(message)
+}
+
+// AcceptInvitation will mark invitation as accepted and add user in the group that they were invited
+// to, with the same checks as adding a user to the group. Accepting an invitation which is already
+// accepted does nothing, so it can be retried safely.
+func (ui *userInvitationController) AcceptInvitation(invitationId, userId uuid.UUID) error {
 	uow := db.NewUnitOfWork(ui.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Model(&models.UserInvitation{}).Where("id = ?", invitation.Id).Updates(map[string]interface{}{
-		"IsAccepted": invitation.IsAccepted,
-	}).Error
+	invitation, err := ui.getInvitation(uow, invitationId)
 	if err != nil {
 		return err
 	}
 
-	if invitation.IsAccepted != nil && *invitation.IsAccepted {
-		err = uow.DB.Create(&models.UserGroup{
-			UserId:  invitation.UserId,
-			GroupId: invitation.GroupId,
-		}).Error
+	if invitation.UserId == nil || *invitation.UserId != userId {
+		return errors.New("invitation not found")
+	}
+
+	if invitation.Status == models.InvitationAccepted {
+		return nil
+	}
+
+	err = ui.updateStatus(uow, &invitation, models.InvitationAccepted)
+	if err != nil {
+		return err
+	}
+
+	err = doesRegisteredUserExist(uow.DB, userId)
+	if err != nil {
+		return err
+	}
+
+	err = ui.doesGroupExist(invitation.GroupId)
+	if err != nil {
+		return err
+	}
+
+	// user who is invited in place of a placeholder inherits its membership and transactions.
+	if invitation.PlaceholderId != nil {
+		err = ui.placeholderCon.Claim(*invitation.PlaceholderId, userId, uow)
+		if err != nil {
+			return err
+		}
+
+		uow.Commit()
+		return nil
+	}
+
+	var totalCount int64
+
+	err = uow.DB.Model(&models.UserGroup{}).
+		Where("user_groups.user_id = ? AND user_groups.group_id = ?", userId, invitation.GroupId).
+		Count(&totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	// user might have joined the group in some other way after they were invited.
+	if totalCount == 0 {
+		err = checkMemberLimit(uow, invitation.GroupId)
+		if err != nil {
+			return err
+		}
+
+		err = addMember(uow, userId, invitation.GroupId, models.RoleMember)
 		if err != nil {
 			return err
 		}
@@ -119,15 +259,46 @@ func (ui *userInvitationController) AcceptInvitation(invitation *models.UserInvi
 	return nil
 }
 
-// GetInvitations will fetch all invitations.
-func (ui *userInvitationController) GetInvitations(invitations *[]models.UserInvitationDTO, parser *util.Parser) error {
+// DeclineInvitation will mark pending invitation as declined. Only the invited user can decline it.
+func (ui *userInvitationController) DeclineInvitation(invitationId, userId uuid.UUID) error {
+	uow := db.NewUnitOfWork(ui.db)
+	defer uow.RollBack()
+
+	invitation, err := ui.getInvitation(uow, invitationId)
+	if err != nil {
+		return err
+	}
+
+	if invitation.UserId == nil || *invitation.UserId != userId {
+		return errors.New("invitation not found")
+	}
+
+	err = ui.updateStatus(uow, &invitation, models.InvitationDeclined)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
 
+// RevokeInvitation will mark pending invitation as revoked, so that it can no longer be accepted.
+// It can be revoked by the inviter or by roles which can remove members.
+func (ui *userInvitationController) RevokeInvitation(invitationId, userId uuid.UUID) error {
 	uow := db.NewUnitOfWork(ui.db)
 	defer uow.RollBack()
 
-	queryDB := ui.searchQuery(uow, parser)
+	invitation, err := ui.getInvitation(uow, invitationId)
+	if err != nil {
+		return err
+	}
+
+	err = ui.checkInviter(uow, &invitation, userId, models.PermissionRemoveMember)
+	if err != nil {
+		return err
+	}
 
-	err := queryDB.Debug().Preload("User").Preload("Group").Preload("InvitedByUser").Find(&invitations).Error
+	err = ui.updateStatus(uow, &invitation, models.InvitationRevoked)
 	if err != nil {
 		return err
 	}
@@ -136,18 +307,142 @@ func (ui *userInvitationController) GetInvitations(invitations *[]models.UserInv
 	return nil
 }
 
-// GetGroupInvitation will fetch all invitations of specified group.
-func (ui *userInvitationController) GetGroupInvitation(invitations *[]models.UserInvitation, groupId uuid.UUID) error {
+// ResendInvitation will make pending or expired invitation pending again with a new expiry. It can be
+// resent by the inviter or by roles which can invite users.
+func (ui *userInvitationController) ResendInvitation(invitationId, userId uuid.UUID) error {
+	uow := db.NewUnitOfWork(ui.db)
+	defer uow.RollBack()
 
-	err := ui.doesGroupExist(groupId)
+	invitation, err := ui.getInvitation(uow, invitationId)
+	if err != nil {
+		return err
+	}
+
+	err = ui.checkInviter(uow, &invitation, userId, models.PermissionInvite)
 	if err != nil {
 		return err
 	}
 
+	err = invitation.Status.CanTransitionTo(models.InvitationPending)
+	if err != nil {
+		return err
+	}
+
+	if invitation.UserId != nil {
+		err = ui.doesUserGroupExist(*invitation.UserId, invitation.GroupId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if invitation.Status == models.InvitationExpired {
+		err = ui.doesPendingInvitationExist(uow, &invitation)
+		if err != nil {
+			return err
+		}
+	}
+
+	expiry := time.Now().Add(models.InvitationExpiry)
+	invitation.ExpiresOn = &expiry
+
+	err = uow.DB.Model(&models.UserInvitation{}).Where("user_invitations.id = ?", invitation.Id).
+		Updates(map[string]interface{}{
+			"Status":    models.InvitationPending,
+			"ExpiresOn": invitation.ExpiresOn,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = ui.sendInvitation(uow, &invitation)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// ExpireInvitations will mark all pending invitations whose expiry has passed as expired and set
+// the number of invitations expired in totalCount.
+func (ui *userInvitationController) ExpireInvitations(totalCount *int64) error {
 	uow := db.NewUnitOfWork(ui.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Where("group_id = ?", groupId).Find(&invitations).Error
+	result := uow.DB.Model(&models.UserInvitation{}).
+		Where("user_invitations.status = ? AND user_invitations.expires_on <= ?", models.InvitationPending, time.Now()).
+		Update("Status", models.InvitationExpired)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	*totalCount = result.RowsAffected
+
+	uow.Commit()
+	return nil
+}
+
+// getInvitation will lock and fetch specified invitation. Pending invitation whose expiry has passed
+// is returned as expired, even if the sweeper has not marked it yet.
+func (ui *userInvitationController) getInvitation(uow *db.UnitOfWork, invitationId uuid.UUID) (models.UserInvitation, error) {
+	invitation := models.UserInvitation{}
+
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_invitations.id = ?", invitationId).First(&invitation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return invitation, errors.New("invitation not found")
+		}
+		return invitation, err
+	}
+
+	if invitation.IsExpired() {
+		invitation.Status = models.InvitationExpired
+	}
+
+	return invitation, nil
+}
+
+// updateStatus will move the invitation to specified status if the transition is allowed.
+func (ui *userInvitationController) updateStatus(uow *db.UnitOfWork, invitation *models.UserInvitation,
+	status models.InvitationStatus) error {
+
+	err := invitation.Status.CanTransitionTo(status)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	invitation.Status = status
+	invitation.RespondedAt = &now
+
+	return uow.DB.Model(&models.UserInvitation{}).Where("user_invitations.id = ?", invitation.Id).
+		Updates(map[string]interface{}{
+			"Status":      invitation.Status,
+			"RespondedAt": invitation.RespondedAt,
+		}).Error
+}
+
+// checkInviter will check if specified user is the inviter or has the permission in the group.
+func (ui *userInvitationController) checkInviter(uow *db.UnitOfWork, invitation *models.UserInvitation,
+	userId uuid.UUID, permission models.GroupPermission) error {
+
+	if invitation.InvitedBy != nil && *invitation.InvitedBy == userId {
+		return nil
+	}
+
+	return checkPermission(uow.DB, userId, invitation.GroupId, permission)
+}
+
+// GetInvitations will fetch all invitations.
+func (ui *userInvitationController) GetInvitations(invitations *[]models.UserInvitationDTO, parser *util.Parser) error {
+
+	uow := db.NewUnitOfWork(ui.db)
+	defer uow.RollBack()
+
+	queryDB := ui.searchQuery(uow, parser)
+
+	err := queryDB.Preload("User").Preload("Group").Preload("InvitedByUser").Find(invitations).Error
 	if err != nil {
 		return err
 	}
@@ -156,10 +451,10 @@ func (ui *userInvitationController) GetGroupInvitation(invitations *[]models.Use
 	return nil
 }
 
-// DeleteInvitation will delete the specified invitation
-func (ui *userInvitationController) DeleteInvitation(invitation *models.UserInvitation) error {
+// GetGroupInvitation will fetch all invitations of specified group.
+func (ui *userInvitationController) GetGroupInvitation(invitations *[]models.UserInvitationDTO, groupId uuid.UUID) error {
 
-	err := ui.doesUserInvitationExist(invitation.Id)
+	err := ui.doesGroupExist(groupId)
 	if err != nil {
 		return err
 	}
@@ -167,7 +462,7 @@ func (ui *userInvitationController) DeleteInvitation(invitation *models.UserInvi
 	uow := db.NewUnitOfWork(ui.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Delete(invitation).Error
+	err = uow.DB.Where("group_id = ?", groupId).Preload("User").Preload("InvitedByUser").Find(invitations).Error
 	if err != nil {
 		return err
 	}
@@ -186,18 +481,6 @@ func (u *userInvitationController) searchQuery(uow *db.UnitOfWork, parser *util.
 	return queryDB
 }
 
-// doesUserExist will check if specified user exist or not.
-func (u *userInvitationController) doesUserExist(userId uuid.UUID) error {
-	err := u.db.Where("users.id = ?", userId).First(&models.User{}).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return errors.New("user not found")
-		}
-		return err
-	}
-	return nil
-}
-
 // doesGroupExist will check if specified group exist or not.
 func (u *userInvitationController) doesGroupExist(groupId uuid.UUID) error {
 	err := u.db.Where("groups.id = ?", groupId).First(&models.Group{}).Error
@@ -224,14 +507,42 @@ func (u *userInvitationController) doesUserGroupExist(userId, groupId uuid.UUID)
 	return nil
 }
 
-// doesUserInvitationExist will check if specified group exist or not.
-func (u *userInvitationController) doesUserInvitationExist(invitationId uuid.UUID) error {
-	err := u.db.Where("id = ?", invitationId).First(&models.UserInvitation{}).Error
+// doesPlaceholderExist will check if specified placeholder is an unclaimed member of the group.
+func (u *userInvitationController) doesPlaceholderExist(placeholderId, groupId uuid.UUID) error {
+	err := u.db.Joins("INNER JOIN user_groups ON user_groups.user_id = users.id AND user_groups.deleted_at IS NULL").
+		Where("users.id = ? AND users.is_placeholder = ? AND users.claimed_by IS NULL AND user_groups.group_id = ?",
+			placeholderId, true, groupId).
+		First(&models.User{}).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return errors.New("invitation not found")
+			return errors.New("placeholder not found in this group")
 		}
 		return err
 	}
 	return nil
 }
+
+// doesPendingInvitationExist will check if the invited user or email already has a pending invitation
+// for the group.
+func (u *userInvitationController) doesPendingInvitationExist(uow *db.UnitOfWork, invitation *models.UserInvitation) error {
+	var totalCount int64
+
+	queryDB := uow.DB.Model(&models.UserInvitation{}).Where("user_invitations.group_id = ?", invitation.GroupId)
+
+	if invitation.UserId != nil {
+		queryDB = queryDB.Where("user_invitations.user_id = ?", *invitation.UserId)
+	} else {
+		queryDB = queryDB.Where("user_invitations.email = ?", *invitation.Email)
+	}
+
+	err := queryDB.Where("user_invitations.status = ? AND user_invitations.expires_on > ?", models.InvitationPending, time.Now()).
+		Count(&totalCount).Error
+	if err != nil {
+		return err
+	}
+
+	if totalCount > 0 {
+		return errors.New("user already invited")
+	}
+	return nil
+}
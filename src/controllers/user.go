@@ -2,17 +2,26 @@ package controllers
 
 import (
 	"errors"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/shaileshhb/equisplit/src/db"
+	"github.com/shaileshhb/equisplit/src/mail"
 	"github.com/shaileshhb/equisplit/src/models"
 	"github.com/shaileshhb/equisplit/src/security"
 	"github.com/shaileshhb/equisplit/src/util"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserController interface {
-	Register(user *models.User) error
-	Login(user *models.User) error
+	Register(user *models.User, session *models.Session, tokens *models.AuthTokens) error
+	VerifyEmail(token string, invitations *[]models.UserInvitationDTO) error
+	ResendVerification(userId uuid.UUID) error
+	Login(user *models.User, session *models.Session, tokens *models.AuthTokens) error
+	LoginMfa(login *models.MfaLogin, user *models.User, session *models.Session, tokens *models.AuthTokens) error
 	GetUser(user *models.UserDTO) error
 	GetUsers(users *[]models.UserDTO, parser *util.Parser) error
 
@@ -21,20 +30,41 @@ type UserController interface {
 }
 
 type userController struct {
-	db *gorm.DB
+	db         *gorm.DB
+	mailer     mail.Sender
+	sessionCon SessionController
+	guard      *loginGuard
 	// rdb *redis.Client
 }
 
-func NewUserController(db *gorm.DB) UserController {
+func NewUserController(db *gorm.DB, mailer mail.Sender) UserController {
 	return &userController{
-		db: db,
+		db:         db,
+		mailer:     mailer,
+		sessionCon: NewSessionController(db),
+		guard:      newLoginGuard(mailer),
 		// rdb: rdb,
 	}
 }
 
-// Register will register new user in the system.
-func (u *userController) Register(user *models.User) error {
-	err := u.validateUser(user)
+// Register will register new user in the system, start specified session for them and email them a
+// link to verify their email. Email of an account which was not verified in time is released for the
+// new user, so that nobody can hold on to an email they do not own.
+func (u *userController) Register(user *models.User, session *models.Session, tokens *models.AuthTokens) error {
+	err := user.ValidateUser()
+	if err != nil {
+		return err
+	}
+
+	uow := db.NewUnitOfWork(u.db)
+	defer uow.RollBack()
+
+	err = u.releaseUnverifiedEmail(uow, user.Email)
+	if err != nil {
+		return err
+	}
+
+	err = u.validateUser(uow, user)
 	if err != nil {
 		return err
 	}
@@ -45,11 +75,71 @@ func (u *userController) Register(user *models.User) error {
 	}
 
 	user.Password = string(password)
+	user.IsPlaceholder = false
+	user.ClaimEmail = nil
+	user.ClaimedBy = nil
+	user.EmailVerified = false
+
+	err = uow.DB.Create(user).Error
+	if err != nil {
+		return err
+	}
+
+	session.UserId = user.Id
+
+	err = u.sessionCon.Create(session, tokens, uow)
+	if err != nil {
+		return err
+	}
+
+	err = u.sendVerification(user)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// VerifyEmail will mark email of the user of specified token as verified. Invitations sent to the
+// email before the user registered are linked to them, and the pending ones are set in invitations.
+func (u *userController) VerifyEmail(token string, invitations *[]models.UserInvitationDTO) error {
+	userId, email, err := security.ValidateVerificationJwt(token)
+	if err != nil {
+		return errors.New("invalid or expired verification token")
+	}
 
 	uow := db.NewUnitOfWork(u.db)
 	defer uow.RollBack()
 
-	err = uow.DB.Create(user).Error
+	user := models.User{}
+
+	err = uow.DB.Where("users.id = ? AND users.email = ?", userId, email).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("invalid or expired verification token")
+		}
+		return err
+	}
+
+	err = uow.DB.Model(&models.User{}).Where("users.id = ?", user.Id).Update("EmailVerified", true).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Model(&models.UserInvitation{}).
+		Where("user_invitations.email = ? AND user_invitations.user_id IS NULL", strings.ToLower(user.Email)).
+		Updates(map[string]interface{}{
+			"UserId": user.Id,
+			"Email":  nil,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = uow.DB.Where("user_invitations.user_id = ? AND user_invitations.status = ?", user.Id, models.InvitationPending).
+		Where("user_invitations.expires_on > ?", time.Now()).
+		Preload("Group").Preload("InvitedByUser").Find(invitations).Error
 	if err != nil {
 		return err
 	}
@@ -58,29 +148,202 @@ func (u *userController) Register(user *models.User) error {
 	return nil
 }
 
-// Login user.
-func (u *userController) Login(user *models.User) error {
+// ResendVerification will email a new verification link to specified user if their email is not
+// verified yet.
+func (u *userController) ResendVerification(userId uuid.UUID) error {
+	user := models.User{}
+
+	err := u.db.Where("users.id = ?", userId).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	if user.EmailVerified {
+		return errors.New("email is already verified")
+	}
+
+	return u.sendVerification(&user)
+}
+
+// sendVerification will email a link to verify the email to specified user.
+func (u *userController) sendVerification(user *models.User) error {
+	expiresOn := time.Now().Add(models.EmailVerificationExpiry)
+
+	token, err := security.GenerateVerificationJwt(user, expiresOn)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Name":  user.Name,
+		"Token": token,
+		"Hours": int(models.EmailVerificationExpiry.Hours()),
+	}
+
+	// link is sent only if the url of the app is known, otherwise the token has to be entered.
+	if appURL := os.Getenv("APP_URL"); appURL != "" {
+		data["Link"] = appURL + "/verify-email?token=" + token
+	}
+
+	message, err := mail.Render(user.Email, "verify-email", data)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(message)
+}
+
+// releaseUnverifiedEmail will remove specified email from the account which registered with it but
+// did not verify it in time. That account can no longer login.
+func (u *userController) releaseUnverifiedEmail(uow *db.UnitOfWork, email string) error {
+	return uow.DB.Unscoped().Model(&models.User{}).
+		Where("users.email = ? AND users.email_verified = ? AND users.is_placeholder = ?", email, false, false).
+		Where("users.created_at < ?", time.Now().Add(-models.EmailVerificationExpiry)).
+		Update("Email", nil).Error
+}
+
+// Login will check credentials of the user and start specified session for them. If the user has
+// enabled two-factor authentication, only a short-lived mfa token is set in tokens and the session is
+// started by LoginMfa. Failed logins make the account and the IP wait longer before the next attempt,
+// until they are locked, and the same error is returned for unknown emails and wrong passwords.
+func (u *userController) Login(user *models.User, session *models.Session, tokens *models.AuthTokens) error {
+	if !u.guard.allowIP(session.IPAddress) {
+		return ErrTooManyLoginAttempts
+	}
 
 	uow := db.NewUnitOfWork(u.db)
 	defer uow.RollBack()
 
 	tempUser := &models.User{}
-	err := uow.DB.Where("users.email = ?", user.Email).First(tempUser).Error
+	err := uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("users.email = ?", user.Email).First(tempUser).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return errors.New("email not registered")
+			if !u.guard.allowEmail(user.Email) {
+				return ErrTooManyLoginAttempts
+			}
+
+			security.SimulatePasswordCheck(user.Password)
+
+			err = u.guard.failEmail(uow, user.Email, session.IPAddress)
+			if err != nil {
+				return err
+			}
+
+			uow.Commit()
+			return errInvalidCredentials
 		}
 		return err
 	}
 
+	if tempUser.IsLocked() {
+		return ErrTooManyLoginAttempts
+	}
+
 	err = security.ComparePassword(tempUser.Password, user.Password)
 	if err != nil {
-		return errors.New("email or password did not match")
+		err = u.guard.fail(uow, tempUser, session.IPAddress)
+		if err != nil {
+			return err
+		}
+
+		// failure is committed even though the login fails.
+		uow.Commit()
+		return errInvalidCredentials
 	}
 
 	user.Id = tempUser.Id
 	user.Name = tempUser.Name
+	user.EmailVerified = tempUser.EmailVerified
+
+	// failed logins are cleared only once the second factor is checked.
+	if tempUser.TotpEnabled {
+		tokens.MfaToken, err = security.GenerateMfaJwt(user.Id, time.Now().Add(models.MfaTokenExpiry))
+		return err
+	}
+
+	err = u.guard.succeed(uow, tempUser)
+	if err != nil {
+		return err
+	}
+
+	session.UserId = user.Id
+
+	err = u.sessionCon.Create(session, tokens, uow)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
+	return nil
+}
+
+// LoginMfa will check the second factor of the user whose password was checked by Login and start
+// specified session for them. Wrong codes count as failed logins.
+func (u *userController) LoginMfa(login *models.MfaLogin, user *models.User, session *models.Session,
+	tokens *models.AuthTokens) error {
+
+	err := login.Validate()
+	if err != nil {
+		return err
+	}
 
+	if !u.guard.allowIP(session.IPAddress) {
+		return ErrTooManyLoginAttempts
+	}
+
+	userId, err := security.ValidateMfaJwt(login.MfaToken)
+	if err != nil {
+		return errors.New("mfa token is invalid or has expired")
+	}
+
+	uow := db.NewUnitOfWork(u.db)
+	defer uow.RollBack()
+
+	err = uow.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Where("users.id = ?", userId).First(user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	if !user.TotpEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if user.IsLocked() {
+		return ErrTooManyLoginAttempts
+	}
+
+	err = verifySecondFactor(uow, user, &login.SecondFactor)
+	if err != nil {
+		failErr := u.guard.fail(uow, user, session.IPAddress)
+		if failErr != nil {
+			return failErr
+		}
+
+		// failure is committed even though the login fails.
+		uow.Commit()
+		return err
+	}
+
+	err = u.guard.succeed(uow, user)
+	if err != nil {
+		return err
+	}
+
+	session.UserId = user.Id
+
+	err = u.sessionCon.Create(session, tokens, uow)
+	if err != nil {
+		return err
+	}
+
+	uow.Commit()
 	return nil
 }
 
@@ -113,10 +376,10 @@ func (u *userController) GetUsers(users *[]models.UserDTO, parser *util.Parser)
 }
 
 // validateUer will check if it is unique user.
-func (u *userController) validateUser(user *models.User) error {
+func (u *userController) validateUser(uow *db.UnitOfWork, user *models.User) error {
 
 	var count int64 = 0
-	err := u.db.Model(&models.User{}).
+	err := uow.DB.Model(&models.User{}).
 		Select("COUNT(DISTINCT(id))").
 		Where("users.id != ? AND users.email = ?", user.Id, user.Email).
 		Unscoped().
@@ -133,18 +396,19 @@ func (u *userController) validateUser(user *models.User) error {
 }
 
 func (u *userController) searchQuery(uow *db.UnitOfWork, parser *util.Parser) *gorm.DB {
-	queryDB := uow.DB
+	// placeholders are members of a single group and cannot be added to other groups.
+	queryDB := uow.DB.Where("users.is_placeholder = ?", false)
 
 	if len(parser.GetQuery("email")) > 0 {
-		queryDB = uow.DB.Where("users.email LIKE ?", parser.GetQuery("email")+"%")
+		queryDB = queryDB.Where("users.email LIKE ?", parser.GetQuery("email")+"%")
 	}
 
 	if len(parser.GetQuery("name")) > 0 {
-		queryDB = uow.DB.Where("users.name LIKE ?", "%"+parser.GetQuery("name")+"%")
+		queryDB = queryDB.Where("users.name LIKE ?", "%"+parser.GetQuery("name")+"%")
 	}
 
 	if len(parser.GetQuery("groupIdNI")) > 0 {
-		queryDB = uow.DB.Joins("LEFT JOIN user_groups ON user_groups.user_id = users.id"+
+		queryDB = queryDB.Joins("LEFT JOIN user_groups ON user_groups.user_id = users.id"+
 			" AND user_groups.group_id IN (?) ", parser.GetQuery("groupIdNI")).
 			Where("user_groups.deleted_at IS NULL AND user_groups.id IS NULL")
 	}
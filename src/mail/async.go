@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// queueSize is the number of emails which can wait to be sent.
+	queueSize = 256
+	// maxAttempts is the number of times an email is tried before it is dropped.
+	maxAttempts = 5
+	// retryDelay is the wait before the first retry. It is doubled after every attempt.
+	retryDelay = 2 * time.Second
+)
+
+type asyncSender struct {
+	sender Sender
+	queue  chan Message
+	log    zerolog.Logger
+}
+
+// NewAsyncSender will create a sender which queues emails and sends them through specified sender
+// in the background, retrying failed emails with backoff.
+func NewAsyncSender(sender Sender, log zerolog.Logger) Sender {
+	a := &asyncSender{
+		sender: sender,
+		queue:  make(chan Message, queueSize),
+		log:    log,
+	}
+
+	go a.run()
+	return a
+}
+
+// Send will queue the message. It only fails if the message is invalid or the queue is full.
+func (a *asyncSender) Send(message Message) error {
+	err := message.Validate()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case a.queue <- message:
+		return nil
+	default:
+		return errors.New("too many emails are waiting to be sent, please try again later")
+	}
+}
+
+// run will send queued emails one at a time.
+func (a *asyncSender) run() {
+	for message := range a.queue {
+		a.deliver(message)
+	}
+}
+
+// deliver will try to send the message until it is sent or maxAttempts is reached.
+func (a *asyncSender) deliver(message Message) {
+	delay := retryDelay
+
+	for attempt := 1; ; attempt++ {
+		err := a.sender.Send(message)
+		if err == nil {
+			return
+		}
+
+		if attempt == maxAttempts {
+			a.log.Error().Err(err).Str("to", message.To).Str("subject", message.Subject).
+				Msg("Email dropped after retries")
+			return
+		}
+
+		a.log.Warn().Err(err).Str("to", message.To).Int("attempt", attempt).Msg("Error sending email, retrying")
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,148 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Message is an email to be sent to a single recipient. HTML is optional, Text is always sent so that
+// clients which do not render HTML can show the email.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender sends emails. Implementation is selected with MAIL_SENDER, so that emails can be delivered
+// locally during development and tests.
+type Sender interface {
+	Send(message Message) error
+}
+
+// NewSender will create the sender specified by MAIL_SENDER. Emails are sent in the background with
+// retries, so that callers do not wait for the mail server.
+//   - console: recipient and subject of emails are written to the log. This is the default.
+//   - outbox: emails are written as .eml files to MAIL_DIR.
+//   - smtp: emails are sent to SMTP_HOST, e.g. a local mail catcher.
+func NewSender(log zerolog.Logger) (Sender, error) {
+	var sender Sender
+	var err error
+
+	switch os.Getenv("MAIL_SENDER") {
+	case "", "console":
+		sender = NewConsoleSender(log)
+	case "outbox":
+		sender, err = NewOutboxSender(os.Getenv("MAIL_DIR"))
+	case "smtp":
+		sender, err = NewSMTPSender(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	default:
+		return nil, errors.New("invalid mail sender " + os.Getenv("MAIL_SENDER"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAsyncSender(sender, log), nil
+}
+
+// Validate will check if the message can be sent.
+func (m *Message) Validate() error {
+	if m.To == "" {
+		return errors.New("recipient must be specified")
+	}
+
+	if m.Subject == "" {
+		return errors.New("subject must be specified")
+	}
+
+	return nil
+}
+
+// Bytes will create the MIME encoded email from specified sender, with text and HTML as alternatives.
+func (m *Message) Bytes(from string) ([]byte, error) {
+	body := bytes.Buffer{}
+	writer := multipart.NewWriter(&body)
+
+	err := writePart(writer, "text/plain; charset=UTF-8", m.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.HTML != "" {
+		err = writePart(writer, "text/html; charset=UTF-8", m.HTML)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	email := bytes.Buffer{}
+	if from != "" {
+		fmt.Fprintf(&email, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&email, "To: %s\r\n", m.To)
+	fmt.Fprintf(&email, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&email, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&email, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&email, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	email.Write(body.Bytes())
+
+	return email.Bytes(), nil
+}
+
+// writePart will add specified content to the email as a quoted-printable part.
+func writePart(writer *multipart.Writer, contentType, content string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := quotedprintable.NewWriter(part)
+	_, err = encoder.Write([]byte(content))
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+type consoleSender struct {
+	log zerolog.Logger
+}
+
+// NewConsoleSender will create a sender which writes emails to the log instead of sending them. Body
+// is not logged, as it can contain verification and reset links. Use outbox sender to read emails.
+func NewConsoleSender(log zerolog.Logger) Sender {
+	return &consoleSender{
+		log: log,
+	}
+}
+
+// Send will write recipient and subject of the message to the log.
+func (c *consoleSender) Send(message Message) error {
+	c.log.Info().Str("to", message.To).Str("subject", message.Subject).Msg("Email not sent by console sender")
+	return nil
+}
@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type outboxSender struct {
+	dir string
+}
+
+// NewOutboxSender will create a sender which writes every email to a separate .eml file in specified
+// directory instead of sending it. The files can be opened with any mail client, and tests can read
+// them to check what was sent.
+func NewOutboxSender(dir string) (Sender, error) {
+	if dir == "" {
+		return nil, errors.New("mail directory must be specified")
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &outboxSender{
+		dir: dir,
+	}, nil
+}
+
+// Send will write the message to a new .eml file named after the time it was sent. File is written
+// under a temporary name and renamed, so that readers never see a partial email.
+func (o *outboxSender) Send(message Message) error {
+	err := message.Validate()
+	if err != nil {
+		return err
+	}
+
+	content, err := message.Bytes(os.Getenv("MAIL_FROM"))
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.New())
+	path := filepath.Join(o.dir, name)
+
+	err = os.WriteFile(path+".tmp", content, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
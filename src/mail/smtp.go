@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+)
+
+// SMTPConfig is the mail server which emails are sent to. Username can be empty for servers which
+// do not need authentication, e.g. a local mail catcher such as Mailpit.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender will create a sender which sends emails to specified SMTP server.
+func NewSMTPSender(config SMTPConfig) (Sender, error) {
+	if config.Host == "" {
+		return nil, errors.New("smtp host must be specified")
+	}
+
+	if config.From == "" {
+		return nil, errors.New("mail from must be specified")
+	}
+
+	if config.Port == "" {
+		config.Port = "25"
+	}
+
+	return &smtpSender{
+		config: config,
+	}, nil
+}
+
+// Send will send the message to the SMTP server. STARTTLS is used if the server supports it.
+func (s *smtpSender) Send(message Message) error {
+	err := message.Validate()
+	if err != nil {
+		return err
+	}
+
+	content, err := message.Bytes(s.config.From)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(s.config.Host, s.config.Port), auth, s.config.From,
+		[]string{message.To}, content)
+}
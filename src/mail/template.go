@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// templates contains a text and an HTML template for every email, e.g. invitation.txt and
+// invitation.html. Subject is the "subject" block of the text template.
+//
+//go:embed templates
+var templates embed.FS
+
+// Render will create the message for specified recipient from the templates of the email with the
+// given name. HTML template is escaped for the data, so user input such as names is safe to use.
+func Render(to, name string, data interface{}) (Message, error) {
+	message := Message{
+		To: to,
+	}
+
+	text, err := texttemplate.ParseFS(templates, "templates/"+name+".txt")
+	if err != nil {
+		return message, err
+	}
+
+	html, err := htmltemplate.ParseFS(templates, "templates/"+name+".html")
+	if err != nil {
+		return message, err
+	}
+
+	buf := bytes.Buffer{}
+
+	err = text.ExecuteTemplate(&buf, "subject", data)
+	if err != nil {
+		return message, err
+	}
+	message.Subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	err = text.Execute(&buf, data)
+	if err != nil {
+		return message, err
+	}
+	message.Text = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	err = html.Execute(&buf, data)
+	if err != nil {
+		return message, err
+	}
+	message.HTML = buf.String()
+
+	return message, nil
+}
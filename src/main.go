@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/shaileshhb/equisplit/src/admin"
+	"github.com/shaileshhb/equisplit/src/balance"
+	"github.com/shaileshhb/equisplit/src/controllers"
 	"github.com/shaileshhb/equisplit/src/db"
 	"github.com/shaileshhb/equisplit/src/log"
+	"github.com/shaileshhb/equisplit/src/mail"
 	"github.com/shaileshhb/equisplit/src/security"
 	"github.com/shaileshhb/equisplit/src/server"
 )
@@ -24,15 +30,67 @@ func main() {
 
 	// Initialize the database
 	database := db.InitDB()
+
+	err = security.InitKeyring()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error loading signing keys")
+	}
+
+	// run administrative command instead of the server, e.g. `equisplit admin rebuild-balances`.
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		err = admin.NewCLI(database, logger, os.Stdout).Run(os.Args[2:])
+		if err != nil {
+			logger.Fatal().Err(err).Msg("")
+		}
+		return
+	}
+
 	// rdb := db.InitCache()
 	// defer rdb.Close()
 	var wg sync.WaitGroup
 
-	auth := security.NewAuthentication(logger)
-	ser := server.NewServer("EquiSplit", database, logger, auth, &wg)
+	mailer, err := mail.NewSender(logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Error creating mail sender")
+	}
+
+	auth := security.NewAuthentication(database, logger)
+	ser := server.NewServer("EquiSplit", database, logger, auth, mailer, &wg)
 	ser.CreateRouterInstance()
 	// db.MigrateTables(ser)
 	ser.MigrateTables()
+
+	// load exchange rates from the local file if specified.
+	if path := os.Getenv("FX_RATES_FILE"); path != "" {
+		var totalCount int
+		err = controllers.NewFxRateController(database).LoadFile(path, &totalCount)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error loading exchange rates")
+		} else {
+			logger.Info().Int("count", totalCount).Msg("Exchange rates loaded")
+		}
+	}
+
+	// reconcile balances periodically if interval is specified, e.g. 24h.
+	if interval := os.Getenv("BALANCE_RECONCILE_INTERVAL"); interval != "" {
+		duration, err := time.ParseDuration(interval)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Invalid BALANCE_RECONCILE_INTERVAL")
+		}
+		fix, _ := strconv.ParseBool(os.Getenv("BALANCE_RECONCILE_FIX"))
+		balance.StartReconcileJob(balance.NewEngine(database), duration, fix, logger)
+	}
+
+	// expire stale invitations periodically, every hour if interval is not specified.
+	sweepInterval := time.Hour
+	if interval := os.Getenv("INVITATION_SWEEP_INTERVAL"); interval != "" {
+		sweepInterval, err = time.ParseDuration(interval)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Invalid INVITATION_SWEEP_INTERVAL")
+		}
+	}
+	controllers.StartInvitationSweeper(controllers.NewUserInvitationController(database, mailer), sweepInterval, logger)
+
 	logger.Error().Err(ser.App.Listen(":8080")).Msg("")
 
 	// Stop Server On System Call or Interrupt.
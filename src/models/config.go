@@ -1,8 +1,11 @@
 package models
 
 import (
+	"fmt"
+	"math"
 	"sync"
 
+	"github.com/samber/lo"
 	"gorm.io/gorm"
 )
 
@@ -28,4 +31,111 @@ func (c *ModuleConfig) TableMigration(wg *sync.WaitGroup) {
 	// if err != nil {
 	// 	fmt.Println(err)
 	// }
+
+	lo.Must0(c.migrateFloatAmounts())
+	lo.Must0(c.migrateBaseAmounts())
+	lo.Must0(c.migratePaidAmounts())
+	lo.Must0(c.migrateGroupOwners())
+	lo.Must0(c.migrateNullableEmail())
+	lo.Must0(c.migrateInvitationStatus())
+}
+
+// floatAmountColumns are the columns which stored amounts as float before Money was introduced.
+var floatAmountColumns = []struct {
+	table  string
+	column string
+}{
+	{table: "groups", column: "total_spent"},
+	{table: "user_groups", column: "incoming_amount"},
+	{table: "user_groups", column: "outgoing_amount"},
+	{table: "group_transactions", column: "amount"},
+	{table: "expenses", column: "amount"},
+	{table: "expense_splits", column: "amount"},
+}
+
+// migrateFloatAmounts will copy amounts from the old float columns into the minor unit columns
+// of Money and drop the float columns. Amounts are rounded to the minor unit of DefaultCurrency.
+func (c *ModuleConfig) migrateFloatAmounts() error {
+	factor := math.Pow10(CurrencyExponent(DefaultCurrency))
+
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		for _, f := range floatAmountColumns {
+			if !tx.Migrator().HasColumn(f.table, f.column) {
+				continue
+			}
+
+			err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s_minor = ROUND(%s * ?), %s_currency = ? WHERE %s IS NOT NULL",
+				f.table, f.column, f.column, f.column, f.column), factor, DefaultCurrency).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Migrator().DropColumn(f.table, f.column)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateBaseAmounts will set base amount of transactions and expenses created before groups had
+// a base currency. All of them were in DefaultCurrency, so base amount is same as the amount.
+func (c *ModuleConfig) migrateBaseAmounts() error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"group_transactions", "expenses"} {
+			err := tx.Exec(fmt.Sprintf("UPDATE %s SET base_amount_minor = amount_minor, base_amount_currency = amount_currency"+
+				" WHERE base_amount_minor = 0 AND amount_minor <> 0", table)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec("UPDATE group_transactions SET date = created_at WHERE date IS NULL").Error
+	})
+}
+
+// migratePaidAmounts will set paid amount of transactions which were marked as paid before
+// settlements were introduced. They were always paid in full.
+func (c *ModuleConfig) migratePaidAmounts() error {
+	return c.DB.Exec("UPDATE group_transactions SET paid_amount_minor = base_amount_minor," +
+		" paid_amount_currency = base_amount_currency WHERE is_paid = true AND paid_amount_minor = 0").Error
+}
+
+// migrateGroupOwners will make the creator of every group which does not have an owner its owner.
+// Groups created before roles were introduced only had the creator as the privileged member.
+func (c *ModuleConfig) migrateGroupOwners() error {
+	return c.DB.Exec("UPDATE user_groups SET role = ? FROM groups WHERE user_groups.group_id = groups.id"+
+		" AND user_groups.user_id = groups.created_by AND user_groups.deleted_at IS NULL AND NOT EXISTS"+
+		" (SELECT 1 FROM user_groups owners WHERE owners.group_id = groups.id AND owners.role = ?"+
+		" AND owners.deleted_at IS NULL)", RoleOwner, RoleOwner).Error
+}
+
+// migrateNullableEmail will allow NULL email for placeholders. AutoMigrate does not drop NOT NULL
+// constraint of existing columns.
+func (c *ModuleConfig) migrateNullableEmail() error {
+	return c.DB.Exec("ALTER TABLE users ALTER COLUMN email DROP NOT NULL").Error
+}
+
+// migrateInvitationStatus will set status of invitations created when only is_accepted was stored
+// and drop is_accepted. Pending invitations which have expired are marked as expired.
+func (c *ModuleConfig) migrateInvitationStatus() error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn("user_invitations", "is_accepted") {
+			return nil
+		}
+
+		err := tx.Exec("UPDATE user_invitations SET status = ? WHERE is_accepted = true", InvitationAccepted).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("UPDATE user_invitations SET status = ? WHERE status = ? AND expires_on <= NOW()",
+			InvitationExpired, InvitationPending).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn("user_invitations", "is_accepted")
+	})
 }
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SplitType specifies how the total of an expense is divided between its participants.
+type SplitType string
+
+const (
+	// SplitEqual divides the total equally between all participants.
+	SplitEqual SplitType = "equal"
+	// SplitExact uses the exact amount specified for every participant.
+	SplitExact SplitType = "exact"
+	// SplitPercentage divides the total using the percentage specified for every participant.
+	SplitPercentage SplitType = "percentage"
+	// SplitShares divides the total in proportion to the shares specified for every participant.
+	SplitShares SplitType = "shares"
+)
+
+// Expense entity. An expense is a single bill paid by one member of the group which is split
+// between the participants. Every participant other than the payer owes their part to the payer
+// and it is recorded as a GroupTransaction linked to the expense.
+// Amount is in the currency the expense was made in and BaseAmount is in the base currency of the group.
+type Expense struct {
+	Base
+	Payer         User           `json:"-" gorm:"foreignKey:PayerId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Group         Group          `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CreatedByUser User           `json:"-" gorm:"foreignKey:CreatedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	PayerId       uuid.UUID      `json:"payerId" gorm:"index;type:uuid"`
+	GroupId       uuid.UUID      `json:"groupId" gorm:"index;type:uuid"`
+	CreatedBy     uuid.UUID      `json:"createdBy" gorm:"index;type:uuid"`
+	Amount        Money          `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	BaseAmount    Money          `json:"baseAmount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	FxRate        float64        `json:"fxRate" gorm:"type:numeric(20,10);default:1"`
+	Description   *string        `json:"description" gorm:"type:text"`
+	Date          time.Time      `json:"date" gorm:"not null"`
+	SplitType     SplitType      `json:"splitType" gorm:"type:varchar(20);not null"`
+	Splits        []ExpenseSplit `json:"splits" gorm:"foreignKey:ExpenseId"`
+}
+
+// TableName specifies name of the table for Expense struct.
+func (*Expense) TableName() string {
+	return "expenses"
+}
+
+func (e *Expense) Validate() error {
+	if e.PayerId == uuid.Nil {
+		return errors.New("payer must be specified")
+	}
+
+	if e.GroupId == uuid.Nil {
+		return errors.New("group must be specified")
+	}
+
+	if !e.Amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+
+	if e.Amount.Currency != "" && !IsValidCurrency(e.Amount.Currency) {
+		return errors.New("invalid currency specified")
+	}
+
+	if e.Description != nil {
+		description := strings.TrimSpace(*e.Description)
+		e.Description = &description
+	}
+
+	switch e.SplitType {
+	case SplitEqual, SplitExact, SplitPercentage, SplitShares:
+	case "":
+		return errors.New("split type must be specified")
+	default:
+		return errors.New("invalid split type specified")
+	}
+
+	if len(e.Splits) == 0 {
+		return errors.New("atleast one participant must be specified")
+	}
+
+	participants := make(map[uuid.UUID]bool, len(e.Splits))
+	for _, s := range e.Splits {
+		if s.UserId == uuid.Nil {
+			return errors.New("participant must be specified")
+		}
+
+		if participants[s.UserId] {
+			return errors.New("participant specified more than once")
+		}
+		participants[s.UserId] = true
+
+		if s.Value < 0 || s.Amount.Minor < 0 {
+			return errors.New("split value cannot be negative")
+		}
+	}
+
+	return nil
+}
+
+// ExpenseSplit entity. Value is the percentage or shares specified for a participant and Amount
+// is the part of the total owed by the participant. For exact split Amount is specified by the user.
+type ExpenseSplit struct {
+	Base
+	User      User      `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ExpenseId uuid.UUID `json:"expenseId" gorm:"index;type:uuid"`
+	UserId    uuid.UUID `json:"userId" gorm:"index;type:uuid"`
+	Value     float64   `json:"value" gorm:"type:float;default:0"`
+	Amount    Money     `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+// TableName specifies name of the table for ExpenseSplit struct.
+func (*ExpenseSplit) TableName() string {
+	return "expense_splits"
+}
+
+// ExpenseDTO entity
+type ExpenseDTO struct {
+	Base
+	Payer        *UserDTO           `json:"payer" gorm:"foreignKey:PayerId"`
+	PayerId      uuid.UUID          `json:"payerId"`
+	GroupId      uuid.UUID          `json:"groupId"`
+	CreatedBy    uuid.UUID          `json:"createdBy"`
+	Amount       Money              `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	BaseAmount   Money              `json:"baseAmount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	FxRate       float64            `json:"fxRate"`
+	Description  *string            `json:"description"`
+	Date         time.Time          `json:"date"`
+	SplitType    SplitType          `json:"splitType"`
+	Splits       []ExpenseSplit     `json:"splits" gorm:"foreignKey:ExpenseId"`
+	Transactions []GroupTransaction `json:"transactions" gorm:"foreignKey:ExpenseId"`
+}
+
+func (*ExpenseDTO) TableName() string {
+	return "expenses"
+}
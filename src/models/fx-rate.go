@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// FxRate entity. Rate is the value of one unit of FromCurrency in ToCurrency on the specified date.
+type FxRate struct {
+	Base
+	FromCurrency string    `json:"fromCurrency" gorm:"type:varchar(3);not null;uniqueIndex:idx_fx_rates_pair_date"`
+	ToCurrency   string    `json:"toCurrency" gorm:"type:varchar(3);not null;uniqueIndex:idx_fx_rates_pair_date"`
+	Date         time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_fx_rates_pair_date"`
+	Rate         float64   `json:"rate" gorm:"type:numeric(20,10);not null"`
+}
+
+// TableName specifies name of the table for FxRate struct.
+func (*FxRate) TableName() string {
+	return "fx_rates"
+}
+
+func (f *FxRate) Validate() error {
+	f.FromCurrency = strings.ToUpper(strings.TrimSpace(f.FromCurrency))
+	f.ToCurrency = strings.ToUpper(strings.TrimSpace(f.ToCurrency))
+
+	if !IsValidCurrency(f.FromCurrency) || !IsValidCurrency(f.ToCurrency) {
+		return errors.New("invalid currency specified")
+	}
+
+	if f.FromCurrency == f.ToCurrency {
+		return errors.New("currencies of an exchange rate must be different")
+	}
+
+	if f.Date.IsZero() {
+		return errors.New("date must be specified")
+	}
+
+	if f.Rate <= 0 {
+		return errors.New("rate must be greater than zero")
+	}
+
+	return nil
+}
+
+// FxRateDTO is used to import exchange rates from a file or request, date is in YYYY-MM-DD format.
+type FxRateDTO struct {
+	Date string  `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultInviteLinkExpiry is the expiry of an invite link if it is not specified.
+	DefaultInviteLinkExpiry = 7 * 24 * time.Hour
+	// MaxInviteLinkExpiry is the maximum expiry an invite link can have.
+	MaxInviteLinkExpiry = 30 * 24 * time.Hour
+)
+
+// GroupInviteLink entity. Anyone with the token of the link can join the group until it expires,
+// is used MaxUses times or is revoked. Token is a JWT of the link and is not stored.
+type GroupInviteLink struct {
+	Base
+	Group         Group     `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CreatedByUser User      `json:"-" gorm:"foreignKey:CreatedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	GroupId       uuid.UUID `json:"groupId" gorm:"index;type:uuid"`
+	CreatedBy     uuid.UUID `json:"createdBy" gorm:"type:uuid"`
+	ExpiresOn     time.Time `json:"expiresOn" gorm:"not null"`
+	// MaxUses is the number of users who can join through the link, it is unlimited if not specified.
+	MaxUses   *int       `json:"maxUses"`
+	UseCount  int        `json:"useCount" gorm:"not null;default:0"`
+	RevokedBy *uuid.UUID `json:"revokedBy" gorm:"type:uuid"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
+
+// TableName specifies name of the table for GroupInviteLink struct.
+func (*GroupInviteLink) TableName() string {
+	return "group_invite_links"
+}
+
+func (g *GroupInviteLink) Validate() error {
+	now := time.Now()
+
+	if g.ExpiresOn.IsZero() {
+		g.ExpiresOn = now.Add(DefaultInviteLinkExpiry)
+	}
+
+	if !g.ExpiresOn.After(now) {
+		return errors.New("expiry must be in the future")
+	}
+
+	if g.ExpiresOn.After(now.Add(MaxInviteLinkExpiry)) {
+		return errors.New("expiry cannot be more than 30 days")
+	}
+
+	if g.MaxUses != nil && *g.MaxUses <= 0 {
+		return errors.New("max uses must be greater than zero")
+	}
+
+	return nil
+}
+
+// IsActive will check if users can still join through the link.
+func (g *GroupInviteLink) IsActive() bool {
+	return g.RevokedAt == nil && g.ExpiresOn.After(time.Now()) && (g.MaxUses == nil || g.UseCount < *g.MaxUses)
+}
+
+// GroupInviteLinkDTO entity
+type GroupInviteLinkDTO struct {
+	Base
+	GroupId       uuid.UUID             `json:"groupId"`
+	CreatedBy     uuid.UUID             `json:"createdBy"`
+	CreatedByUser *UserDTO              `json:"createdByUser" gorm:"foreignKey:CreatedBy"`
+	ExpiresOn     time.Time             `json:"expiresOn"`
+	MaxUses       *int                  `json:"maxUses"`
+	UseCount      int                   `json:"useCount"`
+	Token         string                `json:"token" gorm:"-"`
+	Joins         []GroupInviteLinkJoin `json:"joins" gorm:"foreignKey:LinkId"`
+}
+
+func (*GroupInviteLinkDTO) TableName() string {
+	return "group_invite_links"
+}
+
+// GroupInviteLinkJoin entity. It records the user who joined the group through the link.
+type GroupInviteLinkJoin struct {
+	Base
+	Link    GroupInviteLink `json:"-" gorm:"foreignKey:LinkId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	User    User            `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	LinkId  uuid.UUID       `json:"linkId" gorm:"index;type:uuid"`
+	UserId  uuid.UUID       `json:"userId" gorm:"index;type:uuid"`
+	GroupId uuid.UUID       `json:"groupId" gorm:"index;type:uuid"`
+}
+
+// TableName specifies name of the table for GroupInviteLinkJoin struct.
+func (*GroupInviteLinkJoin) TableName() string {
+	return "group_invite_link_joins"
+}
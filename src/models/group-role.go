@@ -0,0 +1,67 @@
+package models
+
+import "errors"
+
+// GroupRole specifies what a member is allowed to do in a group.
+type GroupRole string
+
+const (
+	// RoleOwner can do everything in the group. Every group has exactly one owner.
+	RoleOwner GroupRole = "owner"
+	// RoleAdmin can manage the group and its members except the owner.
+	RoleAdmin GroupRole = "admin"
+	// RoleMember can add expenses and transactions and invite users.
+	RoleMember GroupRole = "member"
+	// RoleViewer can only view the group.
+	RoleViewer GroupRole = "viewer"
+)
+
+// GroupPermission is an action in a group which is allowed only for some roles.
+type GroupPermission string
+
+const (
+	PermissionRenameGroup          GroupPermission = "rename group"
+	PermissionChangeSettings       GroupPermission = "change settings of the group"
+	PermissionDeleteGroup          GroupPermission = "delete the group"
+	PermissionInvite               GroupPermission = "invite users"
+	PermissionRemoveMember         GroupPermission = "remove members"
+	PermissionManageRoles          GroupPermission = "change roles of members"
+	PermissionAddExpense           GroupPermission = "add expenses and transactions"
+	PermissionDeleteOthersExpenses GroupPermission = "update or delete expenses of others"
+	PermissionResolveBalances      GroupPermission = "resolve balances of members who leave"
+)
+
+// rolePermissions is the permission matrix of the roles.
+var rolePermissions = map[GroupRole][]GroupPermission{
+	RoleOwner: {
+		PermissionRenameGroup, PermissionChangeSettings, PermissionDeleteGroup, PermissionInvite,
+		PermissionRemoveMember, PermissionManageRoles, PermissionAddExpense, PermissionDeleteOthersExpenses,
+		PermissionResolveBalances,
+	},
+	RoleAdmin: {
+		PermissionRenameGroup, PermissionChangeSettings, PermissionInvite, PermissionRemoveMember,
+		PermissionManageRoles, PermissionAddExpense, PermissionDeleteOthersExpenses, PermissionResolveBalances,
+	},
+	RoleMember: {
+		PermissionInvite, PermissionAddExpense,
+	},
+	RoleViewer: {},
+}
+
+// Can will check if the role has specified permission.
+func (r GroupRole) Can(permission GroupPermission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate will check if the role is valid.
+func (r GroupRole) Validate() error {
+	if _, ok := rolePermissions[r]; !ok {
+		return errors.New("invalid role specified")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GroupTransactionRevision entity. A revision is the version of a transaction before it was updated
+// by ChangedBy. ChangedFields contains the comma separated fields which were changed by the update.
+type GroupTransactionRevision struct {
+	Base
+	Transaction        GroupTransaction `json:"-" gorm:"foreignKey:GroupTransactionId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ChangedByUser      User             `json:"-" gorm:"foreignKey:ChangedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	GroupTransactionId uuid.UUID        `json:"groupTransactionId" gorm:"index;type:uuid"`
+	Version            int              `json:"version" gorm:"not null"`
+	ChangedBy          uuid.UUID        `json:"changedBy" gorm:"type:uuid"`
+	ChangedFields      string           `json:"changedFields" gorm:"type:varchar(100)"`
+	PayeeId            uuid.UUID        `json:"payeeId" gorm:"type:uuid"`
+	Amount             Money            `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	BaseAmount         Money            `json:"baseAmount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	FxRate             float64          `json:"fxRate" gorm:"type:numeric(20,10);default:1"`
+	Date               *time.Time       `json:"date"`
+	Description        *string          `json:"description" gorm:"type:text"`
+}
+
+// TableName specifies name of the table for GroupTransactionRevision struct.
+func (*GroupTransactionRevision) TableName() string {
+	return "group_transaction_revisions"
+}
+
+// GroupTransactionRevisionDTO entity
+type GroupTransactionRevisionDTO struct {
+	Base
+	GroupTransactionId uuid.UUID  `json:"groupTransactionId"`
+	Version            int        `json:"version"`
+	ChangedBy          uuid.UUID  `json:"changedBy"`
+	ChangedByUser      *UserDTO   `json:"changedByUser" gorm:"foreignKey:ChangedBy"`
+	ChangedFields      string     `json:"changedFields"`
+	PayeeId            uuid.UUID  `json:"payeeId"`
+	Amount             Money      `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	BaseAmount         Money      `json:"baseAmount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	FxRate             float64    `json:"fxRate"`
+	Date               *time.Time `json:"date"`
+	Description        *string    `json:"description"`
+}
+
+func (*GroupTransactionRevisionDTO) TableName() string {
+	return "group_transaction_revisions"
+}
+
+// GroupTransactionUpdate contains the fields of a transaction which can be updated. Fields which
+// are not specified are left unchanged.
+type GroupTransactionUpdate struct {
+	PayeeId     *uuid.UUID `json:"payeeId"`
+	Amount      *Money     `json:"amount"`
+	Date        *time.Time `json:"date"`
+	Description *string    `json:"description"`
+}
+
+func (g *GroupTransactionUpdate) Validate() error {
+	if g.PayeeId == nil && g.Amount == nil && g.Date == nil && g.Description == nil {
+		return errors.New("atleast one field must be specified")
+	}
+
+	if g.PayeeId != nil && *g.PayeeId == uuid.Nil {
+		return errors.New("payee must be specified")
+	}
+
+	if g.Amount != nil {
+		if !g.Amount.IsPositive() {
+			return errors.New("amount must be greater than zero")
+		}
+
+		if g.Amount.Currency != "" && !IsValidCurrency(g.Amount.Currency) {
+			return errors.New("invalid currency specified")
+		}
+	}
+
+	if g.Description != nil {
+		description := strings.TrimSpace(*g.Description)
+		g.Description = &description
+	}
+
+	return nil
+}
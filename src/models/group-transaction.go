@@ -2,26 +2,38 @@ package models
 
 import (
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-// Payer - Represents the user who has to transfer some amount.
-// Payee - Represents the user to whom the amount should be transferred.
+// Payer - Represents the user who paid and has to receive the amount.
+// Payee - Represents the user who owes the amount to the payer.
+// Amount is in the currency the transaction was made in and BaseAmount is the same amount converted
+// into the base currency of the group using FxRate of the transaction date. Balances use BaseAmount.
+// PaidAmount is the part of BaseAmount covered by settlements, and IsPaid is set once it is fully covered.
 
 // GroupTransaction entity
 type GroupTransaction struct {
 	Base
-	Payer       User      `json:"-" gorm:"foreignKey:PayerId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Payee       User      `json:"-" gorm:"foreignKey:PayeeId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Group       Group     `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	PayerId     uuid.UUID `json:"payerId" gorm:"index;type:uuid"`
-	PayeeId     uuid.UUID `json:"payeeId" gorm:"index;type:uuid"`
-	GroupId     uuid.UUID `json:"groupId" gorm:"index;type:uuid"`
-	Amount      float64   `json:"amount" gorm:"type:float;default:0"`
-	IsPaid      bool      `json:"isPaid" gorm:"default:false"`
-	IsAdjusted  bool      `json:"isAdjusted" gorm:"default:false"`
-	Description *string   `json:"description" gorm:"type:text"`
+	Payer      User       `json:"-" gorm:"foreignKey:PayerId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Payee      User       `json:"-" gorm:"foreignKey:PayeeId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Group      Group      `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Expense    *Expense   `json:"-" gorm:"foreignKey:ExpenseId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	PayerId    uuid.UUID  `json:"payerId" gorm:"index;type:uuid"`
+	PayeeId    uuid.UUID  `json:"payeeId" gorm:"index;type:uuid"`
+	GroupId    uuid.UUID  `json:"groupId" gorm:"index;type:uuid"`
+	Amount     Money      `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	BaseAmount Money      `json:"baseAmount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	FxRate     float64    `json:"fxRate" gorm:"type:numeric(20,10);default:1"`
+	PaidAmount Money      `json:"paidAmount" gorm:"embedded;embeddedPrefix:paid_amount_"`
+	Date       *time.Time `json:"date"`
+	IsPaid     bool       `json:"isPaid" gorm:"default:false"`
+	IsAdjusted bool       `json:"isAdjusted" gorm:"default:false"`
+	// IsSettlement is set for transfers created by a settlement plan. They are not counted as spent.
+	IsSettlement bool       `json:"isSettlement" gorm:"default:false"`
+	Description  *string    `json:"description" gorm:"type:text"`
+	ExpenseId    *uuid.UUID `json:"expenseId" gorm:"index;type:uuid"`
 }
 
 // TableName specifies name of the table for UserGroupHistory struct.
@@ -43,17 +55,62 @@ func (g *GroupTransaction) Validate() error {
 		return errors.New("group must be specified")
 	}
 
-	if g.Amount == 0 {
+	if !g.Amount.IsPositive() {
 		return errors.New("amount must be greater than zero")
 	}
+
+	if g.Amount.Currency != "" && !IsValidCurrency(g.Amount.Currency) {
+		return errors.New("invalid currency specified")
+	}
 	return nil
 }
 
+// GroupTransactionInput is a transaction added by a member. It contains only the fields the member
+// can choose. Payer and group are taken from the request, and base amount, payment and settlement
+// fields are owned by the server.
+type GroupTransactionInput struct {
+	PayeeId     uuid.UUID  `json:"payeeId"`
+	Amount      Money      `json:"amount"`
+	Date        *time.Time `json:"date"`
+	Description *string    `json:"description"`
+}
+
+// Transaction will create the transaction of the input paid by specified payer in the group.
+func (g *GroupTransactionInput) Transaction(payerId, groupId uuid.UUID) GroupTransaction {
+	return GroupTransaction{
+		PayerId:     payerId,
+		PayeeId:     g.PayeeId,
+		GroupId:     groupId,
+		Amount:      g.Amount,
+		Date:        g.Date,
+		Description: g.Description,
+	}
+}
+
+// Outstanding will return the part of base amount which is not yet paid.
+func (g *GroupTransaction) Outstanding() Money {
+	return NewMoney(g.BaseAmount.Minor-g.PaidAmount.Minor, g.BaseAmount.Currency)
+}
+
 // UserBalance represents the balance amount to be paid by other users.
 type UserBalance struct {
 	UserId  uuid.UUID `json:"user_id"`
 	User    UserDTO   `json:"user" gorm:"foreignKey:UserId;"`
 	GroupId uuid.UUID `json:"group_id"`
-	Amount  float64   `json:"amount"`
+	Amount  Money     `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	// OriginalAmounts contains the amount split by the currencies the transactions were made in,
+	// before any settlements.
+	OriginalAmounts []Money `json:"originalAmounts" gorm:"-"`
 	// Group   Group     `json:"group" gorm:"foreignKey:GroupId;"`
 }
+
+// SettlementTransfer represents a payment suggested by the settlement plan of a group.
+// FromUser owes the amount to ToUser.
+type SettlementTransfer struct {
+	GroupId    uuid.UUID `json:"groupId"`
+	FromUserId uuid.UUID `json:"fromUserId"`
+	FromUser   *UserDTO  `json:"fromUser" gorm:"foreignKey:FromUserId"`
+	ToUserId   uuid.UUID `json:"toUserId"`
+	ToUser     *UserDTO  `json:"toUser" gorm:"foreignKey:ToUserId"`
+	Amount     Money     `json:"amount"`
+}
@@ -1,6 +1,12 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
 
 // Group entity
 type Group struct {
@@ -8,8 +14,10 @@ type Group struct {
 	Name       string    `json:"name" gorm:"type:varchar(100);not null;"`
 	User       User      `json:"-" gorm:"foreignKey:CreatedBy"` // added to create foregin key. can't create using constraint
 	CreatedBy  uuid.UUID `json:"createdBy" gorm:"index;type:uuid"`
-	TotalSpent float64   `json:"totalSpent" gorm:"type:float;default:0"`
+	TotalSpent Money     `json:"totalSpent" gorm:"embedded;embeddedPrefix:total_spent_"`
 	Tag        *string   `json:"tag" gorm:"type:varchar(50)"`
+	// BaseCurrency is the currency in which balances of the group are maintained.
+	BaseCurrency string `json:"baseCurrency" gorm:"type:varchar(3);not null;default:'INR'"`
 	// InviteLink string    `json:"inviteLink" gorm:"type:varchar(200)"`
 }
 
@@ -17,14 +25,78 @@ func (*Group) TableName() string {
 	return "groups"
 }
 
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func (g *Group) Validate() error {
+	g.Name = strings.TrimSpace(g.Name)
+	g.BaseCurrency = strings.ToUpper(strings.TrimSpace(g.BaseCurrency))
+
+	if g.Name == "" {
+		return errors.New("name must be specified")
+	}
+
+	if g.BaseCurrency == "" {
+		g.BaseCurrency = DefaultCurrency
+	}
+
+	if !IsValidCurrency(g.BaseCurrency) {
+		return errors.New("invalid base currency specified")
+	}
+
+	return nil
+}
+
+// GroupUpdate contains the group details to be changed. Fields which are not specified are not
+// changed. Empty tag removes the tag of the group.
+type GroupUpdate struct {
+	Name         *string `json:"name"`
+	Tag          *string `json:"tag"`
+	BaseCurrency *string `json:"baseCurrency"`
+}
+
+func (g *GroupUpdate) Validate() error {
+	if g.Name == nil && g.Tag == nil && g.BaseCurrency == nil {
+		return errors.New("atleast one field must be specified")
+	}
+
+	if g.Name != nil {
+		name := strings.TrimSpace(*g.Name)
+		if name == "" {
+			return errors.New("name must be specified")
+		}
+		g.Name = &name
+	}
+
+	if g.Tag != nil {
+		tag := strings.TrimSpace(*g.Tag)
+		g.Tag = &tag
+	}
+
+	if g.BaseCurrency != nil {
+		baseCurrency := strings.ToUpper(strings.TrimSpace(*g.BaseCurrency))
+		if !IsValidCurrency(baseCurrency) {
+			return errors.New("invalid base currency specified")
+		}
+		g.BaseCurrency = &baseCurrency
+	}
+
+	return nil
+}
+
+// IsValidCurrency will check if specified currency is a 3 letter ISO 4217 code.
+func IsValidCurrency(currency string) bool {
+	return currencyCodeRegex.MatchString(currency)
+}
+
 // GroupDTO entity
 type GroupDTO struct {
 	Base
-	Name       string    `json:"name"`
-	User       User      `json:"User" gorm:"foreignKey:CreatedBy"`
-	CreatedBy  uuid.UUID `json:"createdBy"`
-	TotalSpent float64   `json:"totalSpent"`
-	Tag        *string   `json:"tag"`
+	Name         string    `json:"name"`
+	User         User      `json:"User" gorm:"foreignKey:CreatedBy"`
+	CreatedBy    uuid.UUID `json:"createdBy"`
+	TotalSpent   Money     `json:"totalSpent" gorm:"embedded;embeddedPrefix:total_spent_"`
+	Tag          *string   `json:"tag"`
+	BaseCurrency string    `json:"baseCurrency"`
 }
 
 func (*GroupDTO) TableName() string {
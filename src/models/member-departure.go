@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// DepartureResolution specifies how outstanding balance of a member is resolved when they leave or
+// are removed from the group.
+type DepartureResolution string
+
+const (
+	// ResolutionNone is used when the member does not have any outstanding balance.
+	ResolutionNone DepartureResolution = "none"
+	// ResolutionSettle records confirmed settlements for all pending transactions of the member.
+	ResolutionSettle DepartureResolution = "settle"
+	// ResolutionReassign moves all pending transactions of the member to another member.
+	ResolutionReassign DepartureResolution = "reassign"
+	// ResolutionWriteOff marks all pending transactions of the member as adjusted.
+	ResolutionWriteOff DepartureResolution = "write_off"
+)
+
+// MemberDeparture entity. It is the audit entry of a member who left or was removed from the group,
+// with the balance they had and how it was resolved by ResolvedBy.
+type MemberDeparture struct {
+	Base
+	Group          Group               `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	User           User                `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ResolvedByUser User                `json:"-" gorm:"foreignKey:ResolvedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	GroupId        uuid.UUID           `json:"groupId" gorm:"index;type:uuid"`
+	UserId         uuid.UUID           `json:"userId" gorm:"index;type:uuid"`
+	ResolvedBy     uuid.UUID           `json:"resolvedBy" gorm:"type:uuid"`
+	Resolution     DepartureResolution `json:"resolution" gorm:"type:varchar(20);not null"`
+	ReassignedTo   *uuid.UUID          `json:"reassignedTo" gorm:"type:uuid"`
+	IncomingAmount Money               `json:"incomingAmount" gorm:"embedded;embeddedPrefix:incoming_amount_"`
+	OutgoingAmount Money               `json:"outgoingAmount" gorm:"embedded;embeddedPrefix:outgoing_amount_"`
+}
+
+// TableName specifies name of the table for MemberDeparture struct.
+func (*MemberDeparture) TableName() string {
+	return "member_departures"
+}
+
+// DepartureOptions specifies how a member leaves the group. SuccessorId is used when the owner
+// leaves and ReassignTo is used when outstanding balance is reassigned.
+type DepartureOptions struct {
+	SuccessorId *uuid.UUID
+	Resolution  DepartureResolution
+	ReassignTo  *uuid.UUID
+}
+
+func (d *DepartureOptions) Validate() error {
+	if d.Resolution == "" {
+		d.Resolution = ResolutionNone
+	}
+
+	switch d.Resolution {
+	case ResolutionNone, ResolutionSettle, ResolutionWriteOff:
+	case ResolutionReassign:
+		if d.ReassignTo == nil || *d.ReassignTo == uuid.Nil {
+			return errors.New("member to reassign the balance to must be specified")
+		}
+	default:
+		return errors.New("invalid resolution specified")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MfaTokenExpiry is the duration in which the user has to enter their second factor after their
+	// password.
+	MfaTokenExpiry = 5 * time.Minute
+	// RecoveryCodeCount is the number of recovery codes created when two-factor authentication is enabled.
+	RecoveryCodeCount = 10
+)
+
+// RecoveryCode entity. A recovery code can be used once in place of a TOTP code, e.g. when the
+// authenticator device is lost. Only the hash of the code is stored.
+type RecoveryCode struct {
+	Base
+	User     User       `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserId   uuid.UUID  `json:"userId" gorm:"index;not null;type:uuid"`
+	CodeHash string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt   *time.Time `json:"usedAt"`
+}
+
+// TableName specifies name of the table for RecoveryCode struct.
+func (*RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// TotpEnrolment is the secret which the user adds to their authenticator app, as text, as an otpauth
+// URI and as a QR code PNG of the URI.
+type TotpEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	// QRCode is the PNG image, it is base64 encoded in JSON.
+	QRCode []byte `json:"qrCode"`
+}
+
+// SecondFactor is a TOTP code or a recovery code entered by the user.
+type SecondFactor struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+func (s *SecondFactor) Validate() error {
+	if s.Code == "" && s.RecoveryCode == "" {
+		return errors.New("code or recovery code must be specified")
+	}
+	return nil
+}
+
+// MfaLogin is the second step of the login of a user who has enabled two-factor authentication.
+type MfaLogin struct {
+	SecondFactor
+	MfaToken string `json:"mfaToken"`
+}
+
+func (m *MfaLogin) Validate() error {
+	if m.MfaToken == "" {
+		return errors.New("mfa token must be specified")
+	}
+	return m.SecondFactor.Validate()
+}
@@ -0,0 +1,432 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when currency of an amount is not specified.
+const DefaultCurrency = "INR"
+
+// currencyExponents contains the number of minor unit digits of currencies which do not use 2.
+var currencyExponents = map[string]int{
+	"BHD": 3,
+	"CLP": 0,
+	"IDR": 0,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+// CurrencyExponent will return number of minor unit digits for the specified currency.
+func CurrencyExponent(currency string) int {
+	exponent, ok := currencyExponents[strings.ToUpper(currency)]
+	if !ok {
+		return 2
+	}
+	return exponent
+}
+
+// Money represents an exact amount in the minor unit of its currency, e.g. 1050 INR is ₹10.50.
+// Embed it in entities using `gorm:"embedded;embeddedPrefix:<column>_"`.
+type Money struct {
+	Minor    int64  `json:"minor" gorm:"column:minor;type:bigint;not null;default:0"`
+	Currency string `json:"currency" gorm:"column:currency;type:varchar(3);not null;default:'INR'"`
+	// value is the decimal text of an amount decoded without currency. Minor units depend on the
+	// currency, so Minor is only provisional until SetDefaultCurrency parses it.
+	value string
+}
+
+// maxCurrencyExponent is the largest number of minor unit digits of any currency.
+const maxCurrencyExponent = 3
+
+// NewMoney will create money from minor units of specified currency.
+func NewMoney(minor int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{
+		Minor:    minor,
+		Currency: strings.ToUpper(currency),
+	}
+}
+
+// ParseMoney will parse a decimal string like "10.50" into money of specified currency.
+// It returns an error if the value has more decimal places than the currency supports.
+func ParseMoney(value, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	exponent := CurrencyExponent(currency)
+
+	minor, err := parseMinor(value, exponent)
+	if err != nil {
+		if err == errTooManyDecimals {
+			return Money{}, fmt.Errorf("amount can have atmost %d decimal places for %s", exponent, strings.ToUpper(currency))
+		}
+		return Money{}, err
+	}
+
+	return NewMoney(minor, currency), nil
+}
+
+var errTooManyDecimals = errors.New("amount has too many decimal places")
+
+// parseMinor will parse a decimal string into minor units with specified number of minor unit digits.
+func parseMinor(value string, exponent int) (int64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if !isDigits(whole) || !isDigits(fraction) || whole+fraction == "" {
+		return 0, errors.New("invalid amount specified")
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	if len(fraction) > exponent {
+		return 0, errTooManyDecimals
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount specified")
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return minor, nil
+}
+
+// SetDefaultCurrency will set specified currency if the amount was specified without currency. Its
+// decimal value is then parsed with the minor unit digits of the currency, e.g. 100 is 10000 INR but
+// 100 JPY.
+func (m *Money) SetDefaultCurrency(currency string) error {
+	if m.Currency != "" {
+		return nil
+	}
+
+	m.Currency = strings.ToUpper(currency)
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+
+	if m.value == "" {
+		return nil
+	}
+
+	money, err := ParseMoney(m.value, m.Currency)
+	if err != nil {
+		return err
+	}
+
+	m.Minor = money.Minor
+	m.value = ""
+	return nil
+}
+
+// parseValue will parse the decimal value of an amount in specified currency. Without currency the
+// value is kept so that SetDefaultCurrency can parse it once the currency is known, and Minor is
+// only provisional, which is enough to check if the amount is positive.
+func parseValue(value, currency string) (Money, error) {
+	if currency != "" {
+		money, err := ParseMoney(value, currency)
+		if err != nil {
+			return Money{}, err
+		}
+		return Money{Minor: money.Minor, Currency: currency}, nil
+	}
+
+	// value is checked with the most minor unit digits, as the currency is not known yet.
+	minor, err := parseMinor(value, maxCurrencyExponent)
+	if err != nil {
+		if err == errTooManyDecimals {
+			return Money{}, fmt.Errorf("amount can have atmost %d decimal places", maxCurrencyExponent)
+		}
+		return Money{}, err
+	}
+
+	return Money{Minor: minor, value: strings.TrimSpace(value)}, nil
+}
+
+// isDigits will check if value contains only the digits 0-9.
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String will return the decimal representation of the amount without currency, e.g. "10.50".
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	digits := strconv.FormatInt(minor, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// IsZero will check if amount is zero.
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// IsPositive will check if amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// Add will return sum of both amounts. Both amounts must be of the same currency.
+func (m Money) Add(other Money) Money {
+	return NewMoney(m.Minor+other.Minor, m.currency(other))
+}
+
+// Sub will return difference of both amounts. Both amounts must be of the same currency.
+func (m Money) Sub(other Money) Money {
+	return NewMoney(m.Minor-other.Minor, m.currency(other))
+}
+
+// Neg will return the negated amount.
+func (m Money) Neg() Money {
+	return NewMoney(-m.Minor, m.Currency)
+}
+
+// SameCurrency will check if both amounts are of the same currency.
+func (m Money) SameCurrency(other Money) bool {
+	return strings.EqualFold(m.currency(Money{}), other.currency(Money{}))
+}
+
+func (m Money) currency(other Money) string {
+	if m.Currency != "" {
+		return m.Currency
+	}
+	if other.Currency != "" {
+		return other.Currency
+	}
+	return DefaultCurrency
+}
+
+// Convert will convert the amount into specified currency using rate, which is the value of one
+// unit of the amount's currency in the target currency. Result is rounded half away from zero.
+func (m Money) Convert(rate float64, currency string) (Money, error) {
+	if rate <= 0 {
+		return Money{}, errors.New("exchange rate must be greater than zero")
+	}
+
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		return Money{}, errors.New("invalid exchange rate")
+	}
+
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Minor), r)
+
+	// adjust for the difference in minor unit digits of both currencies, e.g. JPY to INR.
+	diff := CurrencyExponent(currency) - CurrencyExponent(m.currency(Money{}))
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(diff))), nil))
+	if diff >= 0 {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+
+	if !quotient.IsInt64() {
+		return Money{}, errors.New("converted amount is too large")
+	}
+
+	return NewMoney(quotient.Int64(), currency), nil
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Allocate will divide the amount in proportion to the specified weights so that the parts always
+// add up exactly to the amount. Every part is first rounded down to the minor unit and the minor
+// units left over are given one each to the parts with the largest remainder. Parts with the same
+// remainder get them in the order they were specified, which makes the result deterministic.
+func (m Money) Allocate(weights []int64) ([]Money, error) {
+	if m.Minor < 0 {
+		return nil, errors.New("negative amount cannot be allocated")
+	}
+
+	var sum uint64
+	for _, w := range weights {
+		if w < 0 {
+			return nil, errors.New("weights cannot be negative")
+		}
+		sum += uint64(w)
+	}
+
+	if sum == 0 {
+		return nil, errors.New("weights must add up to more than zero")
+	}
+
+	type remainder struct {
+		index int
+		value uint64
+	}
+
+	parts := make([]Money, len(weights))
+	remainders := make([]remainder, len(weights))
+
+	var allocated int64
+	for index, w := range weights {
+		hi, lo := bits.Mul64(uint64(m.Minor), uint64(w))
+		if hi >= sum {
+			return nil, errors.New("amount is too large to be allocated")
+		}
+		quotient, rem := bits.Div64(hi, lo, sum)
+
+		parts[index] = NewMoney(int64(quotient), m.Currency)
+		remainders[index] = remainder{index: index, value: rem}
+		allocated += int64(quotient)
+	}
+
+	sort.SliceStable(remainders, func(i, j int) bool {
+		return remainders[i].value > remainders[j].value
+	})
+
+	for i := 0; allocated < m.Minor; i++ {
+		parts[remainders[i].index].Minor++
+		allocated++
+	}
+
+	return parts, nil
+}
+
+// moneyJSON is the representation of money in JSON. Value is the decimal amount as string.
+type moneyJSON struct {
+	Minor    *int64          `json:"minor,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON will encode money as {"minor": 1050, "value": "10.50", "currency": "INR"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	minor := m.Minor
+	value, err := json.Marshal(m.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(moneyJSON{
+		Minor:    &minor,
+		Value:    value,
+		Currency: m.currency(Money{}),
+	})
+}
+
+// UnmarshalJSON will decode money from either minor units or a decimal value which can be
+// specified as string or number, e.g. {"value": "10.50", "currency": "INR"}. A plain number or
+// string, e.g. 10.5, which clients sent before amounts had a currency, is decoded as a value without
+// currency. Currency is left empty when it is not specified, and the caller must apply its default
+// with SetDefaultCurrency, which parses the value with the minor unit digits of that currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] != '{' {
+		return m.unmarshalValue(data)
+	}
+
+	temp := moneyJSON{}
+	err := json.Unmarshal(data, &temp)
+	if err != nil {
+		return errors.New("amount must be specified as an object with value and currency")
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(temp.Currency))
+
+	if temp.Minor != nil {
+		*m = Money{Minor: *temp.Minor, Currency: currency}
+		return nil
+	}
+
+	if len(temp.Value) == 0 {
+		*m = Money{Currency: currency}
+		return nil
+	}
+
+	value := strings.Trim(string(temp.Value), `"`)
+
+	money, err := parseValue(value, currency)
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
+
+// unmarshalValue will decode money from a plain decimal number or string without currency. Number is
+// parsed from its text, so that it is not rounded by float conversion.
+func (m *Money) unmarshalValue(data []byte) error {
+	var value string
+
+	if data[0] == '"' {
+		err := json.Unmarshal(data, &value)
+		if err != nil {
+			return errors.New("invalid amount specified")
+		}
+	} else {
+		var number json.Number
+		err := json.Unmarshal(data, &number)
+		if err != nil {
+			return errors.New("amount must be a number or an object with value and currency")
+		}
+		value = number.String()
+	}
+
+	money, err := parseValue(value, "")
+	if err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
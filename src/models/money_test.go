@@ -0,0 +1,284 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{value: "10.50", currency: "INR", want: NewMoney(1050, "INR")},
+		{value: "10.5", currency: "inr", want: NewMoney(1050, "INR")},
+		{value: "10", currency: "USD", want: NewMoney(1000, "USD")},
+		{value: ".5", currency: "USD", want: NewMoney(50, "USD")},
+		{value: " 7.25 ", currency: "EUR", want: NewMoney(725, "EUR")},
+		{value: "-3.10", currency: "USD", want: NewMoney(-310, "USD")},
+		{value: "+3.10", currency: "USD", want: NewMoney(310, "USD")},
+		{value: "0", currency: "INR", want: NewMoney(0, "INR")},
+		{value: "1500", currency: "JPY", want: NewMoney(1500, "JPY")},
+		{value: "1.234", currency: "KWD", want: NewMoney(1234, "KWD")},
+		{value: "2.5", currency: "", want: NewMoney(250, DefaultCurrency)},
+		{value: "10.505", currency: "INR", wantErr: true},
+		{value: "1.5", currency: "JPY", wantErr: true},
+		{value: "abc", currency: "INR", wantErr: true},
+		{value: "1,000", currency: "INR", wantErr: true},
+		{value: "--5", currency: "INR", wantErr: true},
+		{value: "1.-5", currency: "INR", wantErr: true},
+		{value: "99999999999999999999", currency: "INR", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.currency, func(t *testing.T) {
+			money, err := ParseMoney(tt.value, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", money)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if money != tt.want {
+				t.Errorf("got %v, want %v", money, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(1050, "INR"), want: "10.50"},
+		{money: NewMoney(5, "USD"), want: "0.05"},
+		{money: NewMoney(-5, "USD"), want: "-0.05"},
+		{money: NewMoney(1500, "JPY"), want: "1500"},
+		{money: NewMoney(1234, "KWD"), want: "1.234"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String of %d %s = %s, want %s", tt.money.Minor, tt.money.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Money
+		weights []int64
+		want    []int64
+		wantErr bool
+	}{
+		{name: "even", amount: NewMoney(900, "INR"), weights: []int64{1, 1, 1}, want: []int64{300, 300, 300}},
+		{name: "remainder in order", amount: NewMoney(100, "INR"), weights: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "largest remainder first", amount: NewMoney(100, "INR"), weights: []int64{1, 2, 3},
+			want: []int64{17, 33, 50}},
+		{name: "largest remainder gets the unit", amount: NewMoney(10, "INR"), weights: []int64{3, 3, 4},
+			want: []int64{3, 3, 4}},
+		{name: "two units of remainder", amount: NewMoney(5, "INR"), weights: []int64{1, 1, 1},
+			want: []int64{2, 2, 1}},
+		{name: "zero weight gets nothing", amount: NewMoney(100, "INR"), weights: []int64{1, 0, 1},
+			want: []int64{50, 0, 50}},
+		{name: "zero amount", amount: NewMoney(0, "INR"), weights: []int64{1, 2}, want: []int64{0, 0}},
+		{name: "large amount", amount: NewMoney(9000000000000000000, "INR"), weights: []int64{1, 2},
+			want: []int64{3000000000000000000, 6000000000000000000}},
+		{name: "negative amount", amount: NewMoney(-100, "INR"), weights: []int64{1, 1}, wantErr: true},
+		{name: "negative weight", amount: NewMoney(100, "INR"), weights: []int64{2, -1}, wantErr: true},
+		{name: "zero weights", amount: NewMoney(100, "INR"), weights: []int64{0, 0}, wantErr: true},
+		{name: "no weights", amount: NewMoney(100, "INR"), weights: []int64{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := tt.amount.Allocate(tt.weights)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", parts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sum int64
+			for index, part := range parts {
+				if part.Minor != tt.want[index] {
+					t.Errorf("part %d = %d, want %d", index, part.Minor, tt.want[index])
+				}
+				if part.Currency != tt.amount.Currency {
+					t.Errorf("part %d is in %s, want %s", index, part.Currency, tt.amount.Currency)
+				}
+				sum += part.Minor
+			}
+
+			if sum != tt.amount.Minor {
+				t.Errorf("parts add up to %d, want %d", sum, tt.amount.Minor)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		rate     float64
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{name: "same exponent", amount: NewMoney(1000, "USD"), rate: 83.25, currency: "INR",
+			want: NewMoney(83250, "INR")},
+		{name: "rounds half up", amount: NewMoney(1, "USD"), rate: 0.5, currency: "EUR",
+			want: NewMoney(1, "EUR")},
+		{name: "rounds down below half", amount: NewMoney(1, "USD"), rate: 0.49, currency: "EUR",
+			want: NewMoney(0, "EUR")},
+		{name: "negative rounds half away from zero", amount: NewMoney(-1, "USD"), rate: 0.5, currency: "EUR",
+			want: NewMoney(-1, "EUR")},
+		{name: "to fewer minor digits", amount: NewMoney(1000, "USD"), rate: 150.5, currency: "JPY",
+			want: NewMoney(1505, "JPY")},
+		{name: "to more minor digits", amount: NewMoney(1505, "JPY"), rate: 0.0066445, currency: "USD",
+			want: NewMoney(1000, "USD")},
+		{name: "to three minor digits", amount: NewMoney(1000, "USD"), rate: 0.3075, currency: "KWD",
+			want: NewMoney(3075, "KWD")},
+		{name: "rate of one", amount: NewMoney(1234, "INR"), rate: 1, currency: "INR",
+			want: NewMoney(1234, "INR")},
+		{name: "zero rate", amount: NewMoney(1000, "USD"), rate: 0, currency: "INR", wantErr: true},
+		{name: "negative rate", amount: NewMoney(1000, "USD"), rate: -1, currency: "INR", wantErr: true},
+		{name: "too large", amount: NewMoney(9000000000000000000, "USD"), rate: 100, currency: "INR",
+			wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := tt.amount.Convert(tt.rate, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", money)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if money != tt.want {
+				t.Errorf("got %v, want %v", money, tt.want)
+			}
+		})
+	}
+}
+
+// TestMoneyUnmarshalJSON decodes money and applies the default currency, as controllers do with
+// the base currency of the group.
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{name: "value and currency", data: `{"value": "10.50", "currency": "usd"}`, currency: "INR",
+			want: Money{Minor: 1050, Currency: "USD"}},
+		{name: "numeric value", data: `{"value": 10.5, "currency": "INR"}`, currency: "INR",
+			want: Money{Minor: 1050, Currency: "INR"}},
+		{name: "minor units", data: `{"minor": 1050, "currency": "INR"}`, currency: "INR",
+			want: Money{Minor: 1050, Currency: "INR"}},
+		{name: "value and currency of other group", data: `{"value": "10.50", "currency": "USD"}`, currency: "JPY",
+			want: Money{Minor: 1050, Currency: "USD"}},
+		{name: "without currency", data: `{"value": "10.50"}`, currency: "INR", want: Money{Minor: 1050, Currency: "INR"}},
+		{name: "plain number", data: `10.5`, currency: "INR", want: Money{Minor: 1050, Currency: "INR"}},
+		{name: "plain integer", data: `200`, currency: "INR", want: Money{Minor: 20000, Currency: "INR"}},
+		{name: "plain number without float rounding", data: `0.29`, currency: "INR",
+			want: Money{Minor: 29, Currency: "INR"}},
+		{name: "plain string", data: `"10.50"`, currency: "INR", want: Money{Minor: 1050, Currency: "INR"}},
+		{name: "without default currency", data: `10.5`, want: Money{Minor: 1050, Currency: DefaultCurrency}},
+		{name: "plain integer in JPY", data: `100`, currency: "JPY", want: Money{Minor: 100, Currency: "JPY"}},
+		{name: "plain string in JPY", data: `"1500"`, currency: "jpy", want: Money{Minor: 1500, Currency: "JPY"}},
+		{name: "value in JPY", data: `{"value": "100"}`, currency: "JPY", want: Money{Minor: 100, Currency: "JPY"}},
+		{name: "decimals in JPY", data: `1.5`, currency: "JPY", wantErr: true},
+		{name: "plain number in KWD", data: `1.234`, currency: "KWD", want: Money{Minor: 1234, Currency: "KWD"}},
+		{name: "plain integer in KWD", data: `10`, currency: "KWD", want: Money{Minor: 10000, Currency: "KWD"}},
+		{name: "minor units without currency", data: `{"minor": 100}`, currency: "JPY",
+			want: Money{Minor: 100, Currency: "JPY"}},
+		{name: "null", data: `null`, currency: "INR", want: Money{Currency: "INR"}},
+		{name: "too many decimals", data: `10.505`, currency: "INR", wantErr: true},
+		{name: "too many decimals for any currency", data: `10.5055`, currency: "KWD", wantErr: true},
+		{name: "invalid string", data: `"ten"`, currency: "INR", wantErr: true},
+		{name: "boolean", data: `true`, currency: "INR", wantErr: true},
+		{name: "array", data: `[10]`, currency: "INR", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money := Money{}
+
+			err := json.Unmarshal([]byte(tt.data), &money)
+			if err == nil {
+				err = money.SetDefaultCurrency(tt.currency)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", money)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if money.Minor != tt.want.Minor || money.Currency != tt.want.Currency {
+				t.Errorf("got %d %s, want %d %s", money.Minor, money.Currency, tt.want.Minor, tt.want.Currency)
+			}
+		})
+	}
+}
+
+func TestSetDefaultCurrencyKeepsCurrency(t *testing.T) {
+	money := NewMoney(1050, "USD")
+
+	err := money.SetDefaultCurrency("JPY")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if money != NewMoney(1050, "USD") {
+		t.Errorf("got %d %s, want 1050 USD", money.Minor, money.Currency)
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	money := NewMoney(1050, "USD")
+
+	data, err := json.Marshal(money)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"minor":1050,"value":"10.50","currency":"USD"}` {
+		t.Errorf("got %s", data)
+	}
+
+	decoded := Money{}
+
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != money {
+		t.Errorf("got %v, want %v", decoded, money)
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetExpiry is the duration for which a password reset token can be used after it is issued.
+const PasswordResetExpiry = 30 * time.Minute
+
+// PasswordResetToken entity. Only the hash of the token is stored, the token itself is emailed to the
+// user. Token can be used once, and issuing a new token invalidates the previous ones.
+type PasswordResetToken struct {
+	Base
+	User      User       `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserId    uuid.UUID  `json:"userId" gorm:"index;not null;type:uuid"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresOn time.Time  `json:"expiresOn" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
+
+// TableName specifies name of the table for PasswordResetToken struct.
+func (*PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// IsValid will check if the token can still be used.
+func (p *PasswordResetToken) IsValid() bool {
+	return p.UsedAt == nil && p.ExpiresOn.After(time.Now())
+}
+
+// PasswordReset is the request to set a new password with a reset token.
+type PasswordReset struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (p *PasswordReset) Validate() error {
+	if p.Token == "" {
+		return errors.New("token must be specified")
+	}
+
+	if p.Password == "" {
+		return errors.New("password must be specified")
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountUnlockExpiry is the duration for which the unlock link emailed on a lockout can be used.
+const AccountUnlockExpiry = 24 * time.Hour
+
+// SecurityEventType specifies what happened in a SecurityEvent.
+type SecurityEventType string
+
+const (
+	// SecurityEventAccountLocked is recorded when an account is locked after too many failed logins.
+	SecurityEventAccountLocked SecurityEventType = "account_locked"
+	// SecurityEventAccountUnlocked is recorded when a locked account is unlocked with the emailed link
+	// or by an admin.
+	SecurityEventAccountUnlocked SecurityEventType = "account_unlocked"
+	// SecurityEventIPLocked is recorded when an IP is locked after too many failed logins, e.g. when
+	// it tries many emails.
+	SecurityEventIPLocked SecurityEventType = "ip_locked"
+)
+
+// SecurityEvent entity is a log of security related events of an account or an IP.
+type SecurityEvent struct {
+	Base
+	User   *User             `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserId *uuid.UUID        `json:"userId" gorm:"index;type:uuid"`
+	Type   SecurityEventType `json:"type" gorm:"type:varchar(30);not null;index"`
+	// IPAddress is the IP of the request which caused the event, if any.
+	IPAddress string `json:"ipAddress" gorm:"type:varchar(45)"`
+	Detail    string `json:"detail" gorm:"type:varchar(255)"`
+}
+
+// TableName specifies name of the table for SecurityEvent struct.
+func (*SecurityEvent) TableName() string {
+	return "security_events"
+}
+
+// AccountUnlockToken entity. Only the hash of the token is stored, the token itself is emailed to the
+// user when their account is locked. Token can be used once.
+type AccountUnlockToken struct {
+	Base
+	User      User       `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserId    uuid.UUID  `json:"userId" gorm:"index;not null;type:uuid"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresOn time.Time  `json:"expiresOn" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
+
+// TableName specifies name of the table for AccountUnlockToken struct.
+func (*AccountUnlockToken) TableName() string {
+	return "account_unlock_tokens"
+}
+
+// IsValid will check if the token can still be used.
+func (a *AccountUnlockToken) IsValid() bool {
+	return a.UsedAt == nil && a.ExpiresOn.After(time.Now())
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SessionTouchInterval is how often last seen time of a session is updated when it is used.
+	SessionTouchInterval = time.Minute
+	// AccessTokenExpiry is the duration for which a login token can be used. A new one is issued with
+	// the refresh token of the session.
+	AccessTokenExpiry = 15 * time.Minute
+	// RefreshTokenExpiry is the duration for which a refresh token can be used. Session ends if it is
+	// not refreshed in this time.
+	RefreshTokenExpiry = 30 * 24 * time.Hour
+)
+
+// Session entity. A session is created when the user logs in and lasts until the user logs out, it
+// is revoked or its refresh token expires. Login tokens carry the id of their session in jti.
+type Session struct {
+	Base
+	User         User       `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserId       uuid.UUID  `json:"userId" gorm:"index;not null;type:uuid"`
+	UserAgent    string     `json:"userAgent" gorm:"type:varchar(512)"`
+	IPAddress    string     `json:"ipAddress" gorm:"type:varchar(45)"`
+	LastSeenAt   *time.Time `json:"lastSeenAt"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	RevokeReason string     `json:"revokeReason" gorm:"type:varchar(50)"`
+}
+
+// TableName specifies name of the table for Session struct.
+func (*Session) TableName() string {
+	return "sessions"
+}
+
+// IsActive will check if login tokens of the session can still be used.
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil
+}
+
+// SessionDTO is a session of the user which is shown to them.
+type SessionDTO struct {
+	Base
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	LastSeenAt *time.Time `json:"lastSeenAt"`
+	// Current is true for the session of the request.
+	Current bool `json:"current" gorm:"-"`
+}
+
+// TableName specifies name of the table for SessionDTO struct.
+func (*SessionDTO) TableName() string {
+	return "sessions"
+}
+
+// RefreshToken entity. Refresh token is rotated, every token can be exchanged once for new tokens. A
+// token which is used again is treated as stolen and its session is revoked. Only the hash of the
+// token is stored.
+type RefreshToken struct {
+	Base
+	Session   Session    `json:"-" gorm:"foreignKey:SessionId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	SessionId uuid.UUID  `json:"sessionId" gorm:"index;not null;type:uuid"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresOn time.Time  `json:"expiresOn" gorm:"not null"`
+	RotatedAt *time.Time `json:"rotatedAt"`
+}
+
+// TableName specifies name of the table for RefreshToken struct.
+func (*RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// AuthTokens are the tokens issued for a session.
+type AuthTokens struct {
+	SessionId    uuid.UUID `json:"-"`
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	// ExpiresOn is the expiry of the access token.
+	ExpiresOn time.Time `json:"expiresOn"`
+	// MfaToken is set in place of the other tokens when the user has to complete the login with their
+	// second factor.
+	MfaToken string `json:"mfaToken,omitempty"`
+}
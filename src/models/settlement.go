@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SettlementMethod specifies how the amount of a settlement was paid.
+type SettlementMethod string
+
+const (
+	SettlementCash         SettlementMethod = "cash"
+	SettlementBankTransfer SettlementMethod = "bank_transfer"
+	SettlementUPI          SettlementMethod = "upi"
+	SettlementCard         SettlementMethod = "card"
+	SettlementOther        SettlementMethod = "other"
+)
+
+// SettlementStatus specifies the state of a settlement. A settlement recorded by FromUser is pending
+// until ToUser confirms that the amount was received or rejects it. A settlement recorded by ToUser
+// is confirmed right away. Only confirmed settlements change the balances.
+type SettlementStatus string
+
+const (
+	SettlementPendingConfirmation SettlementStatus = "pending_confirmation"
+	SettlementConfirmed           SettlementStatus = "confirmed"
+	SettlementRejected            SettlementStatus = "rejected"
+)
+
+// Settlement entity. A settlement records a payment made by FromUser, who owes the amount, to
+// ToUser, who has to receive it. The payment can be partial. Once confirmed, BaseAmount is allocated
+// to the pending transactions of the pair, oldest first, or to the transactions specified in TransactionIds.
+type Settlement struct {
+	Base
+	FromUser      User             `json:"-" gorm:"foreignKey:FromUserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ToUser        User             `json:"-" gorm:"foreignKey:ToUserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Group         Group            `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CreatedByUser User             `json:"-" gorm:"foreignKey:CreatedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	FromUserId    uuid.UUID        `json:"fromUserId" gorm:"index;type:uuid"`
+	ToUserId      uuid.UUID        `json:"toUserId" gorm:"index;type:uuid"`
+	GroupId       uuid.UUID        `json:"groupId" gorm:"index;type:uuid"`
+	CreatedBy     uuid.UUID        `json:"createdBy" gorm:"index;type:uuid"`
+	Amount        Money            `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	BaseAmount    Money            `json:"baseAmount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	FxRate        float64          `json:"fxRate" gorm:"type:numeric(20,10);default:1"`
+	Method        SettlementMethod `json:"method" gorm:"type:varchar(20);not null"`
+	Date          time.Time        `json:"date" gorm:"not null"`
+	Note          *string          `json:"note" gorm:"type:text"`
+	Status        SettlementStatus `json:"status" gorm:"type:varchar(30);not null;default:'confirmed'"`
+	RespondedBy   *uuid.UUID       `json:"respondedBy" gorm:"type:uuid"`
+	RespondedAt   *time.Time       `json:"respondedAt"`
+	// RejectionReason is specified by ToUser when the settlement is rejected.
+	RejectionReason *string                `json:"rejectionReason" gorm:"type:text"`
+	Allocations     []SettlementAllocation `json:"allocations" gorm:"foreignKey:SettlementId"`
+	Transactions    []GroupTransaction     `json:"-" gorm:"many2many:settlement_transactions;"`
+	// TransactionIds optionally restricts the transactions the settlement is allocated to.
+	TransactionIds []uuid.UUID `json:"transactionIds" gorm:"-"`
+}
+
+// TableName specifies name of the table for Settlement struct.
+func (*Settlement) TableName() string {
+	return "settlements"
+}
+
+func (s *Settlement) Validate() error {
+	if s.FromUserId == uuid.Nil {
+		return errors.New("from user must be specified")
+	}
+
+	if s.ToUserId == uuid.Nil {
+		return errors.New("to user must be specified")
+	}
+
+	if s.FromUserId == s.ToUserId {
+		return errors.New("from and to user cannot be same")
+	}
+
+	if s.GroupId == uuid.Nil {
+		return errors.New("group must be specified")
+	}
+
+	if !s.Amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+
+	if s.Amount.Currency != "" && !IsValidCurrency(s.Amount.Currency) {
+		return errors.New("invalid currency specified")
+	}
+
+	switch s.Method {
+	case SettlementCash, SettlementBankTransfer, SettlementUPI, SettlementCard, SettlementOther:
+	case "":
+		s.Method = SettlementOther
+	default:
+		return errors.New("invalid payment method specified")
+	}
+
+	if s.Note != nil {
+		note := strings.TrimSpace(*s.Note)
+		s.Note = &note
+	}
+
+	return nil
+}
+
+// SettlementInput is a payment recorded by a member. It contains only the fields the member can
+// choose. Group and creator are taken from the request, and base amount, status and allocations are
+// owned by the server.
+type SettlementInput struct {
+	FromUserId uuid.UUID        `json:"fromUserId"`
+	ToUserId   uuid.UUID        `json:"toUserId"`
+	Amount     Money            `json:"amount"`
+	Method     SettlementMethod `json:"method"`
+	Date       time.Time        `json:"date"`
+	Note       *string          `json:"note"`
+	// TransactionIds optionally restricts the transactions the settlement is allocated to.
+	TransactionIds []uuid.UUID `json:"transactionIds"`
+}
+
+// Settlement will create the settlement of the input recorded by specified user in the group.
+func (s *SettlementInput) Settlement(createdBy, groupId uuid.UUID) Settlement {
+	return Settlement{
+		FromUserId:     s.FromUserId,
+		ToUserId:       s.ToUserId,
+		GroupId:        groupId,
+		CreatedBy:      createdBy,
+		Amount:         s.Amount,
+		Method:         s.Method,
+		Date:           s.Date,
+		Note:           s.Note,
+		TransactionIds: s.TransactionIds,
+	}
+}
+
+// SettlementAllocation entity. It is the part of a settlement which covers a transaction, in base
+// currency of the group.
+type SettlementAllocation struct {
+	Base
+	Transaction        GroupTransaction `json:"-" gorm:"foreignKey:GroupTransactionId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	SettlementId       uuid.UUID        `json:"settlementId" gorm:"index;type:uuid"`
+	GroupTransactionId uuid.UUID        `json:"groupTransactionId" gorm:"index;type:uuid"`
+	Amount             Money            `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+// TableName specifies name of the table for SettlementAllocation struct.
+func (*SettlementAllocation) TableName() string {
+	return "settlement_allocations"
+}
+
+// SettlementDTO entity
+type SettlementDTO struct {
+	Base
+	FromUserId  uuid.UUID        `json:"fromUserId"`
+	FromUser    *UserDTO         `json:"fromUser" gorm:"foreignKey:FromUserId"`
+	ToUserId    uuid.UUID        `json:"toUserId"`
+	ToUser      *UserDTO         `json:"toUser" gorm:"foreignKey:ToUserId"`
+	GroupId     uuid.UUID        `json:"groupId"`
+	CreatedBy   uuid.UUID        `json:"createdBy"`
+	Amount      Money            `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	BaseAmount  Money            `json:"baseAmount" gorm:"embedded;embeddedPrefix:base_amount_"`
+	FxRate      float64          `json:"fxRate"`
+	Method      SettlementMethod `json:"method"`
+	Date        time.Time        `json:"date"`
+	Note        *string          `json:"note"`
+	Status      SettlementStatus `json:"status"`
+	RespondedBy *uuid.UUID       `json:"respondedBy"`
+	RespondedAt *time.Time       `json:"respondedAt"`
+	// RejectionReason is specified by ToUser when the settlement is rejected.
+	RejectionReason *string                `json:"rejectionReason"`
+	Allocations     []SettlementAllocation `json:"allocations" gorm:"foreignKey:SettlementId"`
+}
+
+func (*SettlementDTO) TableName() string {
+	return "settlements"
+}
@@ -2,6 +2,15 @@ package models
 
 import "github.com/google/uuid"
 
+// MemberStatus specifies if a user is still a member of the group. Users who left the group are
+// soft deleted from user_groups.
+type MemberStatus string
+
+const (
+	MemberActive MemberStatus = "active"
+	MemberLeft   MemberStatus = "left"
+)
+
 // UserGroup entity
 type UserGroup struct {
 	Base
@@ -9,8 +18,9 @@ type UserGroup struct {
 	Group          Group     `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	UserId         uuid.UUID `json:"userId" gorm:"index;type:uuid"`
 	GroupId        uuid.UUID `json:"groupId" gorm:"index;type:uuid"`
-	OutgoingAmount float64   `json:"outgoingAmount" gorm:"type:float;default:0"`
-	IncomingAmount float64   `json:"incomingAmount" gorm:"type:float;default:0"`
+	Role           GroupRole `json:"role" gorm:"type:varchar(20);not null;default:'member'"`
+	OutgoingAmount Money     `json:"outgoingAmount" gorm:"embedded;embeddedPrefix:outgoing_amount_"`
+	IncomingAmount Money     `json:"incomingAmount" gorm:"embedded;embeddedPrefix:incoming_amount_"`
 }
 
 func (*UserGroup) TableName() string {
@@ -24,8 +34,10 @@ type UserGroupDTO struct {
 	Group          *Group        `json:"group"`
 	UserId         uuid.UUID     `json:"userId"`
 	GroupId        uuid.UUID     `json:"groupId"`
-	OutgoingAmount float64       `json:"outgoingAmount"`
-	IncomingAmount float64       `json:"incomingAmount"`
+	Role           GroupRole     `json:"role"`
+	Status         MemberStatus  `json:"status" gorm:"-"`
+	OutgoingAmount Money         `json:"outgoingAmount" gorm:"embedded;embeddedPrefix:outgoing_amount_"`
+	IncomingAmount Money         `json:"incomingAmount" gorm:"embedded;embeddedPrefix:incoming_amount_"`
 	Summary        *GroupSummary `json:"summary" gorm:"-"`
 }
 
@@ -36,6 +48,6 @@ func (*UserGroupDTO) TableName() string {
 // GroupSummary will contain details of how much a user has outgoing and incoming amount
 type GroupSummary struct {
 	UserId         uuid.UUID `json:"userId"`
-	OutgoingAmount float64   `json:"outgoingAmount"`
-	IncomingAmount float64   `json:"incomingAmount"`
+	OutgoingAmount Money     `json:"outgoingAmount" gorm:"embedded;embeddedPrefix:outgoing_amount_"`
+	IncomingAmount Money     `json:"incomingAmount" gorm:"embedded;embeddedPrefix:incoming_amount_"`
 }
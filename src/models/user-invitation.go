@@ -2,22 +2,63 @@ package models
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// UserInvitation entity
+// InvitationExpiry is the duration for which an invitation can be accepted after it is sent.
+const InvitationExpiry = 30 * 24 * time.Hour
+
+// InvitationStatus specifies the state of an invitation. An invitation is pending until the user
+// accepts or declines it, the inviter revokes it or it expires. Pending and expired invitations can
+// be resent, which makes them pending again with a new expiry.
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+// invitationTransitions contains the states an invitation can move to from every state.
+var invitationTransitions = map[InvitationStatus][]InvitationStatus{
+	InvitationPending:  {InvitationPending, InvitationAccepted, InvitationDeclined, InvitationRevoked, InvitationExpired},
+	InvitationExpired:  {InvitationPending},
+	InvitationAccepted: {},
+	InvitationDeclined: {},
+	InvitationRevoked:  {},
+}
+
+// CanTransitionTo will check if an invitation in this state can move to specified state.
+func (s InvitationStatus) CanTransitionTo(status InvitationStatus) error {
+	for _, next := range invitationTransitions[s] {
+		if next == status {
+			return nil
+		}
+	}
+	return errors.New("invitation which is " + string(s) + " cannot be " + string(status))
+}
+
+// UserInvitation entity. Person who has not registered yet is invited by email, and the invitation
+// is linked to them when they register with that email.
 type UserInvitation struct {
 	Base
-	User          User       `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Group         Group      `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	InvitedByUser User       `json:"-" gorm:"foreignKey:InvitedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	UserId        uuid.UUID  `json:"userId" gorm:"index;type:uuid"`
-	GroupId       uuid.UUID  `json:"groupId" gorm:"index;not null;type:uuid"`
-	InvitedBy     *uuid.UUID `json:"invitedBy" gorm:"index;not null;type:uuid"`
-	ExpiresOn     *time.Time `json:"expiresOn" gorm:"not null"`
-	IsAccepted    *bool      `json:"isAccepted" gorm:"default:false;not null"`
+	User          User             `json:"-" gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Group         Group            `json:"-" gorm:"foreignKey:GroupId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	InvitedByUser User             `json:"-" gorm:"foreignKey:InvitedBy;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserId        *uuid.UUID       `json:"userId" gorm:"index;type:uuid"`
+	Email         *string          `json:"email" gorm:"type:varchar(255);index"`
+	GroupId       uuid.UUID        `json:"groupId" gorm:"index;not null;type:uuid"`
+	InvitedBy     *uuid.UUID       `json:"invitedBy" gorm:"index;not null;type:uuid"`
+	ExpiresOn     *time.Time       `json:"expiresOn" gorm:"not null"`
+	Status        InvitationStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	RespondedAt   *time.Time       `json:"respondedAt"`
+	// PlaceholderId is the placeholder which is claimed by the user when the invitation is accepted.
+	PlaceholderId *uuid.UUID `json:"placeholderId" gorm:"type:uuid"`
 }
 
 func (*UserInvitation) TableName() string {
@@ -25,8 +66,24 @@ func (*UserInvitation) TableName() string {
 }
 
 func (u *UserInvitation) Validate() error {
-	if u.UserId == uuid.Nil {
-		return errors.New("user must be specified")
+	if u.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*u.Email))
+		u.Email = &email
+		if email == "" {
+			u.Email = nil
+		}
+	}
+
+	if u.UserId != nil && *u.UserId == uuid.Nil {
+		u.UserId = nil
+	}
+
+	if u.UserId == nil && u.Email == nil {
+		return errors.New("user or email must be specified")
+	}
+
+	if u.UserId != nil && u.Email != nil {
+		return errors.New("either user or email must be specified")
 	}
 
 	if u.GroupId == uuid.Nil {
@@ -40,17 +97,25 @@ func (u *UserInvitation) Validate() error {
 	return nil
 }
 
+// IsExpired will check if the invitation is pending but can no longer be accepted.
+func (u *UserInvitation) IsExpired() bool {
+	return u.Status == InvitationPending && u.ExpiresOn != nil && !u.ExpiresOn.After(time.Now())
+}
+
 // UserInvitationDTO entity
 type UserInvitationDTO struct {
 	Base
-	User          *User      `json:"user" gorm:"foreignKey:UserId"`
-	Group         *Group     `json:"group" gorm:"foreignKey:GroupId"`
-	InvitedByUser *User      `json:"invitedByUser" gorm:"foreignKey:InvitedBy"`
-	InvitedBy     *uuid.UUID `json:"invitedBy"`
-	UserId        uuid.UUID  `json:"userId"`
-	GroupId       uuid.UUID  `json:"groupId"`
-	ExpiresOn     *time.Time `json:"-"`
-	IsAccepted    *bool      `json:"isAccepted"`
+	User          *User            `json:"user" gorm:"foreignKey:UserId"`
+	Group         *Group           `json:"group" gorm:"foreignKey:GroupId"`
+	InvitedByUser *User            `json:"invitedByUser" gorm:"foreignKey:InvitedBy"`
+	InvitedBy     *uuid.UUID       `json:"invitedBy"`
+	UserId        *uuid.UUID       `json:"userId"`
+	Email         *string          `json:"email"`
+	GroupId       uuid.UUID        `json:"groupId"`
+	ExpiresOn     *time.Time       `json:"expiresOn"`
+	Status        InvitationStatus `json:"status"`
+	RespondedAt   *time.Time       `json:"respondedAt"`
+	PlaceholderId *uuid.UUID       `json:"placeholderId"`
 }
 
 func (*UserInvitationDTO) TableName() string {
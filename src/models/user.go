@@ -2,15 +2,54 @@ package models
 
 import (
 	"errors"
+	"net/mail"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
-// User db entity
+// User db entity. A placeholder is a name-only user who is a member of a single group and cannot
+// login. It can later be claimed by a registered user, who then inherits its transactions. Email of a
+// placeholder is stored as NULL so that the unique index allows many placeholders.
 type User struct {
 	Base
 	Name     string `json:"name" gorm:"type:varchar(80);not null"`
-	Email    string `json:"email" gorm:"uniqueIndex;not null"`
+	Email    string `json:"email" gorm:"uniqueIndex"`
 	Password string `json:"password" gorm:"not null"`
+	// IsPlaceholder is true for name-only members who have not registered.
+	IsPlaceholder bool `json:"isPlaceholder" gorm:"default:false;not null"`
+	// ClaimEmail is the email of the person a placeholder represents. Registered user with this email
+	// can claim the placeholder.
+	ClaimEmail *string `json:"claimEmail" gorm:"type:varchar(255);index"`
+	// ClaimedBy is the user who claimed the placeholder.
+	ClaimedBy *uuid.UUID `json:"claimedBy" gorm:"type:uuid"`
+	// PasswordChangedAt is when the password was last reset. All sessions are revoked when it is reset.
+	PasswordChangedAt *time.Time `json:"-"`
+	// EmailVerified is true once the user has opened the verification email. Unverified users cannot
+	// invite others or be invited.
+	EmailVerified bool `json:"emailVerified" gorm:"default:false;not null"`
+	// TotpSecret is the secret of the authenticator app of the user. It is set when the user starts
+	// enrolment, and two-factor authentication is on only once TotpEnabled is set.
+	TotpSecret  *string `json:"-" gorm:"type:varchar(64)"`
+	TotpEnabled bool    `json:"totpEnabled" gorm:"default:false;not null"`
+	// TotpLastCounter is the counter of the last TOTP code used, so that a code cannot be used twice.
+	TotpLastCounter int64 `json:"-" gorm:"default:0;not null"`
+	// FailedLoginCount is the number of failed logins since the last successful login. Each failure
+	// after a few makes the user wait longer before the next attempt, until the account is locked.
+	FailedLoginCount  int        `json:"-" gorm:"default:0;not null"`
+	LastFailedLoginAt *time.Time `json:"-"`
+	// LockedUntil is the time until which the user cannot login.
+	LockedUntil *time.Time `json:"-"`
+}
+
+// EmailVerificationExpiry is the duration for which the verification email can be used. Email of an
+// account which is not verified in this time can be registered by someone else.
+const EmailVerificationExpiry = 24 * time.Hour
+
+// IsLocked will check if the user has to wait before they can login again.
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(time.Now())
 }
 
 func (*User) TableName() string {
@@ -29,14 +68,41 @@ func (u *User) ValidateUser() error {
 		return errors.New("email must be specified")
 	}
 
+	address, err := mail.ParseAddress(u.Email)
+	if err != nil || address.Address != u.Email {
+		return errors.New("email is not valid")
+	}
+
+	return nil
+}
+
+// ValidatePlaceholder will validate details of a placeholder.
+func (u *User) ValidatePlaceholder() error {
+	u.Name = strings.TrimSpace(u.Name)
+
+	if u.Name == "" {
+		return errors.New("name must be specified")
+	}
+
+	if u.ClaimEmail != nil {
+		email := strings.ToLower(strings.TrimSpace(*u.ClaimEmail))
+		u.ClaimEmail = &email
+		if email == "" {
+			u.ClaimEmail = nil
+		}
+	}
+
 	return nil
 }
 
 // User db entity
 type UserDTO struct {
 	BaseDTO
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	IsPlaceholder bool   `json:"isPlaceholder"`
+	EmailVerified bool   `json:"emailVerified"`
+	TotpEnabled   bool   `json:"totpEnabled"`
 }
 
 func (*UserDTO) TableName() string {
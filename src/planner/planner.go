@@ -0,0 +1,80 @@
+package planner
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Transfer represents a single payment of Amount from the debtor to the creditor.
+type Transfer struct {
+	From   uuid.UUID
+	To     uuid.UUID
+	Amount int64
+}
+
+// position is the net amount of a member. Positive amount is owed to the member and
+// negative amount is owed by the member.
+type position struct {
+	userId uuid.UUID
+	amount int64
+}
+
+// Plan will return a small set of transfers which settles all the specified net positions.
+// Positions are in minor units and must add up to zero. Largest debtor always pays the largest
+// creditor, so at most n-1 transfers are created for n members and the result is deterministic,
+// though it is not always the fewest transfers possible.
+func Plan(positions map[uuid.UUID]int64) []Transfer {
+	debtors := []position{}
+	creditors := []position{}
+
+	for userId, amount := range positions {
+		if amount < 0 {
+			debtors = append(debtors, position{userId: userId, amount: -amount})
+		}
+		if amount > 0 {
+			creditors = append(creditors, position{userId: userId, amount: amount})
+		}
+	}
+
+	sortPositions(debtors)
+	sortPositions(creditors)
+
+	transfers := []Transfer{}
+
+	for len(debtors) > 0 && len(creditors) > 0 {
+		debtor, creditor := &debtors[0], &creditors[0]
+
+		amount := min(debtor.amount, creditor.amount)
+		transfers = append(transfers, Transfer{
+			From:   debtor.userId,
+			To:     creditor.userId,
+			Amount: amount,
+		})
+
+		debtor.amount -= amount
+		creditor.amount -= amount
+
+		if debtor.amount == 0 {
+			debtors = debtors[1:]
+		}
+		if creditor.amount == 0 {
+			creditors = creditors[1:]
+		}
+
+		sortPositions(debtors)
+		sortPositions(creditors)
+	}
+
+	return transfers
+}
+
+// sortPositions will sort positions by amount in descending order and by user for same amount.
+func sortPositions(positions []position) {
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].amount != positions[j].amount {
+			return positions[i].amount > positions[j].amount
+		}
+		return positions[i].userId.String() < positions[j].userId.String()
+	})
+}
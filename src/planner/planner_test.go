@@ -0,0 +1,140 @@
+package planner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// member will return a fixed user id, so that ties are broken in a known order.
+func member(n byte) uuid.UUID {
+	return uuid.UUID{15: n}
+}
+
+// settle will apply the transfers to the positions and check that every member is settled.
+func settle(t *testing.T, positions map[uuid.UUID]int64, transfers []Transfer) {
+	t.Helper()
+
+	remaining := make(map[uuid.UUID]int64, len(positions))
+	for userId, amount := range positions {
+		remaining[userId] = amount
+	}
+
+	for _, transfer := range transfers {
+		if transfer.Amount <= 0 {
+			t.Errorf("transfer of %d from %s to %s is not positive", transfer.Amount, transfer.From, transfer.To)
+		}
+		if transfer.From == transfer.To {
+			t.Errorf("transfer from %s to itself", transfer.From)
+		}
+
+		remaining[transfer.From] += transfer.Amount
+		remaining[transfer.To] -= transfer.Amount
+	}
+
+	for userId, amount := range remaining {
+		if amount != 0 {
+			t.Errorf("%s is left with %d", userId, amount)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	a, b, c, d := member(1), member(2), member(3), member(4)
+
+	tests := []struct {
+		name      string
+		positions map[uuid.UUID]int64
+		want      []Transfer
+	}{
+		{
+			name:      "no members",
+			positions: map[uuid.UUID]int64{},
+			want:      []Transfer{},
+		},
+		{
+			name:      "single settled member",
+			positions: map[uuid.UUID]int64{a: 0},
+			want:      []Transfer{},
+		},
+		{
+			name:      "all members settled",
+			positions: map[uuid.UUID]int64{a: 0, b: 0, c: 0},
+			want:      []Transfer{},
+		},
+		{
+			name:      "one debtor and one creditor",
+			positions: map[uuid.UUID]int64{a: 500, b: -500},
+			want:      []Transfer{{From: b, To: a, Amount: 500}},
+		},
+		{
+			name:      "one creditor and many debtors",
+			positions: map[uuid.UUID]int64{a: 900, b: -300, c: -200, d: -400},
+			want: []Transfer{
+				{From: d, To: a, Amount: 400},
+				{From: b, To: a, Amount: 300},
+				{From: c, To: a, Amount: 200},
+			},
+		},
+		{
+			name:      "largest debtor pays largest creditor",
+			positions: map[uuid.UUID]int64{a: 700, b: 300, c: -600, d: -400},
+			want: []Transfer{
+				{From: c, To: a, Amount: 600},
+				{From: d, To: b, Amount: 300},
+				{From: d, To: a, Amount: 100},
+			},
+		},
+		{
+			name:      "ties are broken by user",
+			positions: map[uuid.UUID]int64{a: 100, b: 100, c: -100, d: -100},
+			want: []Transfer{
+				{From: c, To: a, Amount: 100},
+				{From: d, To: b, Amount: 100},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfers := Plan(tt.positions)
+
+			if !reflect.DeepEqual(transfers, tt.want) {
+				t.Errorf("got %v, want %v", transfers, tt.want)
+			}
+
+			settle(t, tt.positions, transfers)
+		})
+	}
+}
+
+func TestPlanAtMostOneLessThanMembers(t *testing.T) {
+	positions := map[uuid.UUID]int64{}
+
+	var sum int64
+	for n := byte(1); n < 20; n++ {
+		amount := int64(n) * 137 % 1000
+		if n%2 == 0 {
+			amount = -amount
+		}
+		positions[member(n)] = amount
+		sum += amount
+	}
+	positions[member(20)] = -sum
+
+	transfers := Plan(positions)
+
+	if len(transfers) > len(positions)-1 {
+		t.Errorf("got %d transfers for %d members", len(transfers), len(positions))
+	}
+
+	settle(t, positions, transfers)
+
+	// result does not depend on the order of the map.
+	for i := 0; i < 10; i++ {
+		if again := Plan(positions); !reflect.DeepEqual(again, transfers) {
+			t.Fatalf("plan is not deterministic: got %v, want %v", again, transfers)
+		}
+	}
+}
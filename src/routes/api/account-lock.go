@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/security"
+)
+
+type AccountLockRouter interface {
+	RegisterRoutes(router fiber.Router)
+	unlock(c *fiber.Ctx) error
+	unlockUser(c *fiber.Ctx) error
+}
+
+type accountLockRouter struct {
+	con  controllers.AccountLockController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewAccountLockRouter will create new instance of AccountLockRouter.
+func NewAccountLockRouter(con controllers.AccountLockController, auth security.Authentication, log zerolog.Logger) AccountLockRouter {
+	return &accountLockRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register account lock routes.
+func (a *accountLockRouter) RegisterRoutes(router fiber.Router) {
+	router.Get("/account/unlock/:token", a.unlock)
+	router.Post("/admin/users/:userId<uuid>/unlock", a.auth.AdminMiddleware, a.unlockUser)
+
+	a.log.Info().Msg("Account lock routes registered")
+}
+
+// unlock will unlock the account of the user with the token emailed to them when it was locked.
+func (a *accountLockRouter) unlock(c *fiber.Ctx) error {
+	a.log.Info().Msg("========= unlock route called =========")
+
+	err := a.con.Unlock(c.Params("token"), c.IP())
+	if err != nil {
+		a.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"unlocked": true,
+	})
+}
+
+// unlockUser will unlock the account of specified user on behalf of an admin.
+func (a *accountLockRouter) unlockUser(c *fiber.Ctx) error {
+	a.log.Info().Msg("========= unlockUser route called =========")
+
+	userId, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		a.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = a.con.UnlockUser(userId, c.IP())
+	if err != nil {
+		a.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"unlocked": true,
+	})
+}
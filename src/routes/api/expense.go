@@ -0,0 +1,215 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"github.com/shaileshhb/equisplit/src/util"
+)
+
+type ExpenseRouter interface {
+	RegisterRoutes(router fiber.Router)
+	add(c *fiber.Ctx) error
+	update(c *fiber.Ctx) error
+	delete(c *fiber.Ctx) error
+	getExpense(c *fiber.Ctx) error
+	getGroupExpenses(c *fiber.Ctx) error
+}
+
+type expenseRouter struct {
+	con  controllers.ExpenseController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewExpenseRouter will create new instance of ExpenseRouter.
+func NewExpenseRouter(con controllers.ExpenseController, auth security.Authentication, log zerolog.Logger) ExpenseRouter {
+	return &expenseRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register routes for expense router.
+func (e *expenseRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/group/:groupId<uuid>/expense", e.auth.MandatoryAuthMiddleware, e.auth.GroupMemberMiddleware, e.add)
+	router.Get("/group/:groupId<uuid>/expenses", e.auth.MandatoryAuthMiddleware, e.auth.GroupMemberMiddleware, e.getGroupExpenses)
+	router.Get("/expense/:expenseId<uuid>", e.auth.MandatoryAuthMiddleware, e.auth.GroupMemberMiddleware, e.getExpense)
+	router.Put("/expense/:expenseId<uuid>", e.auth.MandatoryAuthMiddleware, e.auth.GroupMemberMiddleware, e.update)
+	router.Delete("/expense/:expenseId<uuid>", e.auth.MandatoryAuthMiddleware, e.auth.GroupMemberMiddleware, e.delete)
+	e.log.Info().Msg("Expense routes registered")
+}
+
+// add will add new expense in specified group and split it between the participants.
+func (e *expenseRouter) add(c *fiber.Ctx) error {
+	e.log.Info().Msg("========= add expense route called =========")
+	expense := models.Expense{}
+
+	err := c.BodyParser(&expense)
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	expense.GroupId, err = uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	expense.CreatedBy = user.Id
+	if expense.PayerId == uuid.Nil {
+		expense.PayerId = user.Id
+	}
+
+	err = expense.Validate()
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = e.con.Add(&expense)
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(expense)
+}
+
+// update will update specified expense and regenerate its transactions.
+func (e *expenseRouter) update(c *fiber.Ctx) error {
+	e.log.Info().Msg("========= update expense route called =========")
+	expense := models.Expense{}
+
+	err := c.BodyParser(&expense)
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	expense.Id, err = uuid.Parse(c.Params("expenseId"))
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	if expense.PayerId == uuid.Nil {
+		expense.PayerId = user.Id
+	}
+
+	err = e.con.Update(&expense, user.Id)
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(expense)
+}
+
+// delete will delete specified expense along with its transactions.
+func (e *expenseRouter) delete(c *fiber.Ctx) error {
+	e.log.Info().Msg("========= delete expense route called =========")
+
+	expenseId, err := uuid.Parse(c.Params("expenseId"))
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = e.con.Delete(user.Id, expenseId)
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// getExpense will fetch specified expense with its splits and transactions.
+func (e *expenseRouter) getExpense(c *fiber.Ctx) error {
+	e.log.Info().Msg("========= getExpense route called =========")
+	expense := models.ExpenseDTO{}
+
+	var err error
+	expense.Id, err = uuid.Parse(c.Params("expenseId"))
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = e.con.GetExpense(&expense)
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(expense)
+}
+
+// getGroupExpenses will fetch all expenses of specified group.
+func (e *expenseRouter) getGroupExpenses(c *fiber.Ctx) error {
+	e.log.Info().Msg("========= getGroupExpenses route called =========")
+	expenses := []models.ExpenseDTO{}
+	parser := util.NewParser(c)
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var totalCount int64
+
+	err = e.con.GetGroupExpenses(&expenses, groupId, &totalCount, parser)
+	if err != nil {
+		e.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Response().Header.Add("X-Total-Count", strconv.Itoa(int(totalCount)))
+
+	return c.Status(http.StatusOK).JSON(expenses)
+}
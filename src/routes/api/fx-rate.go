@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"github.com/shaileshhb/equisplit/src/util"
+)
+
+type FxRateRouter interface {
+	RegisterRoutes(router fiber.Router)
+	importRates(c *fiber.Ctx) error
+	getRates(c *fiber.Ctx) error
+}
+
+type fxRateRouter struct {
+	con  controllers.FxRateController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewFxRateRouter will create new instance of FxRateRouter.
+func NewFxRateRouter(con controllers.FxRateController, auth security.Authentication, log zerolog.Logger) FxRateRouter {
+	return &fxRateRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register routes for fx rate router.
+func (f *fxRateRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/fx-rates", f.auth.AdminMiddleware, f.importRates)
+	router.Get("/fx-rates", f.auth.MandatoryAuthMiddleware, f.getRates)
+	f.log.Info().Msg("FxRate routes registered")
+}
+
+// importRates will add exchange rates sent as JSON array or as CSV with text/csv content type.
+func (f *fxRateRouter) importRates(c *fiber.Ctx) error {
+	f.log.Info().Msg("========= importRates route called =========")
+
+	format := "json"
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), "text/csv") {
+		format = "csv"
+	}
+
+	var totalCount int
+
+	err := f.con.ImportRates(c.Body(), format, &totalCount)
+	if err != nil {
+		f.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"imported": totalCount,
+	})
+}
+
+// getRates will fetch all exchange rates.
+func (f *fxRateRouter) getRates(c *fiber.Ctx) error {
+	f.log.Info().Msg("========= getRates route called =========")
+	rates := []models.FxRate{}
+	parser := util.NewParser(c)
+
+	err := f.con.GetRates(&rates, parser)
+	if err != nil {
+		f.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(rates)
+}
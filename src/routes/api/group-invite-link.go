@@ -0,0 +1,170 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+)
+
+type GroupInviteLinkRouter interface {
+	RegisterRoutes(router fiber.Router)
+	add(c *fiber.Ctx) error
+	getActiveLinks(c *fiber.Ctx) error
+	revoke(c *fiber.Ctx) error
+	join(c *fiber.Ctx) error
+}
+
+type groupInviteLinkRouter struct {
+	con  controllers.GroupInviteLinkController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewGroupInviteLinkRouter will create new instance of GroupInviteLinkRouter.
+func NewGroupInviteLinkRouter(con controllers.GroupInviteLinkController, auth security.Authentication,
+	log zerolog.Logger) GroupInviteLinkRouter {
+	return &groupInviteLinkRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register routes for group invite link router.
+func (g *groupInviteLinkRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/group/:groupId<uuid>/invite-link", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.add)
+	router.Get("/group/:groupId<uuid>/invite-links", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.getActiveLinks)
+	router.Delete("/invite-link/:inviteLinkId<uuid>", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.revoke)
+	router.Post("/join/:token", g.auth.MandatoryAuthMiddleware, g.join)
+	g.log.Info().Msg("Group invite link routes registered")
+}
+
+// add will create new invite link for specified group.
+func (g *groupInviteLinkRouter) add(c *fiber.Ctx) error {
+	g.log.Info().Msg("========= add invite link route called =========")
+	link := models.GroupInviteLink{}
+
+	err := c.BodyParser(&link)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	link.GroupId, err = uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	link.CreatedBy = user.Id
+
+	err = link.Validate()
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var token string
+
+	err = g.con.Add(&link, &token)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"id":        link.Id,
+		"token":     token,
+		"expiresOn": link.ExpiresOn,
+		"maxUses":   link.MaxUses,
+	})
+}
+
+// getActiveLinks will fetch invite links of specified group which can still be used.
+func (g *groupInviteLinkRouter) getActiveLinks(c *fiber.Ctx) error {
+	g.log.Info().Msg("========= getActiveLinks route called =========")
+	links := []models.GroupInviteLinkDTO{}
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = g.con.GetActiveLinks(&links, user.Id, groupId)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(links)
+}
+
+// revoke will revoke specified invite link.
+func (g *groupInviteLinkRouter) revoke(c *fiber.Ctx) error {
+	g.log.Info().Msg("========= revoke invite link route called =========")
+
+	linkId, err := uuid.Parse(c.Params("inviteLinkId"))
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = g.con.Revoke(linkId, user.Id)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// join will add the logged in user to the group of the invite link.
+func (g *groupInviteLinkRouter) join(c *fiber.Ctx) error {
+	g.log.Info().Msg("========= join route called =========")
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	groupId, err := g.con.Join(c.Params("token"), user.Id)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"groupId": groupId,
+	})
+}
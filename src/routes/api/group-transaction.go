@@ -15,7 +15,11 @@ type GroupTransactionRouter interface {
 	RegisterRoutes(router fiber.Router)
 	add(c *fiber.Ctx) error
 	markTransactionPaid(c *fiber.Ctx) error
+	update(c *fiber.Ctx) error
+	getRevisions(c *fiber.Ctx) error
 	delete(c *fiber.Ctx) error
+	getSettlementPlan(c *fiber.Ctx) error
+	applySettlementPlan(c *fiber.Ctx) error
 }
 
 type groupTransactionRouter struct {
@@ -35,19 +39,23 @@ func NewGroupTransactionRouter(con controllers.GroupTransactionController, auth
 
 // RegisterRoutes will register routes for user-group router.
 func (g *groupTransactionRouter) RegisterRoutes(router fiber.Router) {
-	router.Post("/group/:groupId<uuid>/transaction", g.auth.MandatoryAuthMiddleware, g.add)
-	router.Post("/group/:groupId<uuid>/transactions", g.auth.MandatoryAuthMiddleware, g.addMultiple)
-	router.Put("/transaction/:transactionId<uuid>", g.auth.MandatoryAuthMiddleware, g.markTransactionPaid)
-	router.Delete("/transaction/:transactionId<uuid>", g.auth.MandatoryAuthMiddleware, g.delete)
-	router.Get("/group/:groupId/transactions", g.auth.MandatoryAuthMiddleware, g.getTransactionDetails)
+	router.Post("/group/:groupId<uuid>/transaction", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.add)
+	router.Post("/group/:groupId<uuid>/transactions", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.addMultiple)
+	router.Put("/transaction/:transactionId<uuid>", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.markTransactionPaid)
+	router.Patch("/transaction/:transactionId<uuid>", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.update)
+	router.Get("/transaction/:transactionId<uuid>/revisions", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.getRevisions)
+	router.Delete("/transaction/:transactionId<uuid>", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.delete)
+	router.Get("/group/:groupId<uuid>/transactions", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.getTransactionDetails)
+	router.Get("/group/:groupId<uuid>/settlement-plan", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.getSettlementPlan)
+	router.Post("/group/:groupId<uuid>/settlement-plan", g.auth.MandatoryAuthMiddleware, g.auth.GroupMemberMiddleware, g.applySettlementPlan)
 	g.log.Info().Msg("GroupTransaction routes registered")
 }
 
 // add will add new transaction for specified group and user.
 func (g *groupTransactionRouter) add(c *fiber.Ctx) error {
-	transaction := models.GroupTransaction{}
+	input := models.GroupTransactionInput{}
 
-	err := c.BodyParser(&transaction)
+	err := c.BodyParser(&input)
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -66,8 +74,7 @@ func (g *groupTransactionRouter) add(c *fiber.Ctx) error {
 	userInterface := c.Locals("user")
 	user := userInterface.(*models.User)
 
-	transaction.PayerId = user.Id
-	transaction.GroupId = id
+	transaction := input.Transaction(user.Id, id)
 
 	err = transaction.Validate()
 	if err != nil {
@@ -90,9 +97,9 @@ func (g *groupTransactionRouter) add(c *fiber.Ctx) error {
 
 // addMultiple will add new transaction in specified group.
 func (g *groupTransactionRouter) addMultiple(c *fiber.Ctx) error {
-	transactions := []models.GroupTransaction{}
+	inputs := []models.GroupTransactionInput{}
 
-	err := c.BodyParser(&transactions)
+	err := c.BodyParser(&inputs)
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -111,9 +118,10 @@ func (g *groupTransactionRouter) addMultiple(c *fiber.Ctx) error {
 	userInterface := c.Locals("user")
 	user := userInterface.(*models.User)
 
-	for index := range transactions {
-		transactions[index].GroupId = groupId
-		transactions[index].PayerId = user.Id
+	transactions := make([]models.GroupTransaction, len(inputs))
+
+	for index := range inputs {
+		transactions[index] = inputs[index].Transaction(user.Id, groupId)
 
 		err = transactions[index].Validate()
 		if err != nil {
@@ -151,7 +159,6 @@ func (g *groupTransactionRouter) markTransactionPaid(c *fiber.Ctx) error {
 
 	userInterface := c.Locals("user")
 	user := userInterface.(*models.User)
-	transaction.PayeeId = user.Id
 
 	err = g.con.MarkTransactionPaid(&transaction, user.Id)
 	if err != nil {
@@ -164,6 +171,75 @@ func (g *groupTransactionRouter) markTransactionPaid(c *fiber.Ctx) error {
 	return c.Status(http.StatusAccepted).JSON(nil)
 }
 
+// update will update specified fields of the transaction.
+func (g *groupTransactionRouter) update(c *fiber.Ctx) error {
+	transaction := models.GroupTransaction{}
+	update := models.GroupTransactionUpdate{}
+
+	err := c.BodyParser(&update)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	transaction.Id, err = uuid.Parse(c.Params("transactionId"))
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = update.Validate()
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = g.con.Update(&transaction, &update, user.Id)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(transaction)
+}
+
+// getRevisions will fetch change history of specified transaction.
+func (g *groupTransactionRouter) getRevisions(c *fiber.Ctx) error {
+	revisions := []models.GroupTransactionRevisionDTO{}
+
+	transactionId, err := uuid.Parse(c.Params("transactionId"))
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = g.con.GetRevisions(&revisions, transactionId, user.Id)
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(revisions)
+}
+
 // delete will delete specified user from group
 func (u *groupTransactionRouter) delete(c *fiber.Ctx) error {
 
@@ -214,3 +290,52 @@ func (u *groupTransactionRouter) getTransactionDetails(c *fiber.Ctx) error {
 
 	return c.Status(http.StatusOK).JSON(userBalances)
 }
+
+// getSettlementPlan will fetch a small set of transfers which settles all debts of specified group.
+func (u *groupTransactionRouter) getSettlementPlan(c *fiber.Ctx) error {
+	transfers := []models.SettlementTransfer{}
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = u.con.GetSettlementPlan(&transfers, groupId)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(transfers)
+}
+
+// applySettlementPlan will replace pending transactions of specified group with its settlement plan.
+func (u *groupTransactionRouter) applySettlementPlan(c *fiber.Ctx) error {
+	transfers := []models.SettlementTransfer{}
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = u.con.ApplySettlementPlan(&transfers, user.Id, groupId)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(transfers)
+}
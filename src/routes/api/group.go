@@ -38,10 +38,12 @@ func NewGroupRouter(con controllers.GroupController, auth security.Authenticatio
 
 // RegisterRoutes will register routes for group.
 func (g *groupRouter) RegisterRoutes(router fiber.Router) {
-	router.Get("/user/:userId<uuid>/groups", g.auth.MandatoryAuthMiddleware, g.getUserGroups)
-	router.Post("/user/:userId<uuid>/group", g.auth.MandatoryAuthMiddleware, g.createGroup)
-	router.Put("/user/:userId<uuid>/group/:groupId<uuid>", g.auth.MandatoryAuthMiddleware, g.updateGroup)
-	router.Delete("/user/:userId<uuid>/group/:groupId<uuid>", g.auth.MandatoryAuthMiddleware, g.deleteGroup)
+	router.Get("/user/:userId<uuid>/groups", g.auth.MandatoryAuthMiddleware, g.auth.SelfMiddleware, g.getUserGroups)
+	router.Post("/user/:userId<uuid>/group", g.auth.MandatoryAuthMiddleware, g.auth.SelfMiddleware, g.createGroup)
+	router.Put("/user/:userId<uuid>/group/:groupId<uuid>", g.auth.MandatoryAuthMiddleware, g.auth.SelfMiddleware,
+		g.auth.GroupMemberMiddleware, g.updateGroup)
+	router.Delete("/user/:userId<uuid>/group/:groupId<uuid>", g.auth.MandatoryAuthMiddleware, g.auth.SelfMiddleware,
+		g.auth.GroupMemberMiddleware, g.deleteGroup)
 
 	g.log.Info().Msg("Group routes registered")
 }
@@ -67,6 +69,14 @@ func (g *groupRouter) createGroup(c *fiber.Ctx) error {
 		})
 	}
 
+	err = group.Validate()
+	if err != nil {
+		g.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	err = g.con.CreateGroup(group)
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
@@ -82,8 +92,9 @@ func (g *groupRouter) createGroup(c *fiber.Ctx) error {
 func (g *groupRouter) updateGroup(c *fiber.Ctx) error {
 	g.log.Info().Msg("========= updateGroup route called =========")
 	group := &models.Group{}
+	update := models.GroupUpdate{}
 
-	err := c.BodyParser(group)
+	err := c.BodyParser(&update)
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -91,7 +102,10 @@ func (g *groupRouter) updateGroup(c *fiber.Ctx) error {
 		})
 	}
 
-	group.CreatedBy, err = uuid.Parse(c.Params("userId"))
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	group.Id, err = uuid.Parse(c.Params("groupId"))
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -99,7 +113,7 @@ func (g *groupRouter) updateGroup(c *fiber.Ctx) error {
 		})
 	}
 
-	group.Id, err = uuid.Parse(c.Params("groupId"))
+	err = update.Validate()
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -107,7 +121,7 @@ func (g *groupRouter) updateGroup(c *fiber.Ctx) error {
 		})
 	}
 
-	err = g.con.CreateGroup(group)
+	err = g.con.UpdateGroup(group, &update, user.Id)
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -123,14 +137,7 @@ func (g *groupRouter) deleteGroup(c *fiber.Ctx) error {
 	g.log.Info().Msg("========= deleteGroup route called =========")
 	group := &models.Group{}
 
-	userId, err := uuid.Parse(c.Params("userId"))
-	if err != nil {
-		g.log.Error().Err(err).Msg("")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
+	var err error
 	group.Id, err = uuid.Parse(c.Params("groupId", "0"))
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
@@ -139,9 +146,10 @@ func (g *groupRouter) deleteGroup(c *fiber.Ctx) error {
 		})
 	}
 
-	group.CreatedBy = userId
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
 
-	err = g.con.DeleteGroup(group)
+	err = g.con.DeleteGroup(group, user.Id)
 	if err != nil {
 		g.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+)
+
+type MfaRouter interface {
+	RegisterRoutes(router fiber.Router)
+	enrollTotp(c *fiber.Ctx) error
+	enableTotp(c *fiber.Ctx) error
+	disableTotp(c *fiber.Ctx) error
+	regenerateRecoveryCodes(c *fiber.Ctx) error
+}
+
+type mfaRouter struct {
+	con  controllers.MfaController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewMfaRouter will create new instance of MfaRouter.
+func NewMfaRouter(con controllers.MfaController, auth security.Authentication, log zerolog.Logger) MfaRouter {
+	return &mfaRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register two-factor authentication routes.
+func (m *mfaRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/me/mfa/totp", m.auth.MandatoryAuthMiddleware, m.enrollTotp)
+	router.Post("/me/mfa/totp/verify", m.auth.MandatoryAuthMiddleware, m.enableTotp)
+	router.Delete("/me/mfa/totp", m.auth.MandatoryAuthMiddleware, m.disableTotp)
+	router.Post("/me/mfa/recovery-codes", m.auth.MandatoryAuthMiddleware, m.regenerateRecoveryCodes)
+
+	m.log.Info().Msg("Mfa routes registered")
+}
+
+// enrollTotp will create a TOTP secret for the logged in user and return it with its QR code.
+func (m *mfaRouter) enrollTotp(c *fiber.Ctx) error {
+	m.log.Info().Msg("========= enrollTotp route called =========")
+	enrolment := models.TotpEnrolment{}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err := m.con.EnrollTotp(user.Id, &enrolment)
+	if err != nil {
+		m.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(enrolment)
+}
+
+// enableTotp will turn on two-factor authentication for the logged in user and return their
+// recovery codes.
+func (m *mfaRouter) enableTotp(c *fiber.Ctx) error {
+	m.log.Info().Msg("========= enableTotp route called =========")
+	factor := models.SecondFactor{}
+	recoveryCodes := []string{}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err := c.BodyParser(&factor)
+	if err != nil {
+		m.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = m.con.EnableTotp(user.Id, factor.Code, &recoveryCodes)
+	if err != nil {
+		m.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"totpEnabled": true,
+		// recovery codes are shown only once.
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// disableTotp will turn off two-factor authentication for the logged in user.
+func (m *mfaRouter) disableTotp(c *fiber.Ctx) error {
+	m.log.Info().Msg("========= disableTotp route called =========")
+	factor := models.SecondFactor{}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err := c.BodyParser(&factor)
+	if err != nil {
+		m.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = m.con.DisableTotp(user.Id, &factor)
+	if err != nil {
+		m.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(nil)
+}
+
+// regenerateRecoveryCodes will replace recovery codes of the logged in user.
+func (m *mfaRouter) regenerateRecoveryCodes(c *fiber.Ctx) error {
+	m.log.Info().Msg("========= regenerateRecoveryCodes route called =========")
+	factor := models.SecondFactor{}
+	recoveryCodes := []string{}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err := c.BodyParser(&factor)
+	if err != nil {
+		m.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = m.con.RegenerateRecoveryCodes(user.Id, &factor, &recoveryCodes)
+	if err != nil {
+		m.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"recoveryCodes": recoveryCodes,
+	})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+)
+
+type PasswordRouter interface {
+	RegisterRoutes(router fiber.Router)
+	forgotPassword(c *fiber.Ctx) error
+	resetPassword(c *fiber.Ctx) error
+}
+
+type passwordRouter struct {
+	con  controllers.PasswordController
+	auth security.Authentication
+	log  zerolog.Logger
+	// ipLimiter and emailLimiter limit forgot password requests, so that it cannot be used to flood
+	// a mailbox or the mail server.
+	ipLimiter    *security.RateLimiter
+	emailLimiter *security.RateLimiter
+}
+
+// NewPasswordRouter will create new instance of PasswordRouter.
+func NewPasswordRouter(con controllers.PasswordController, auth security.Authentication, log zerolog.Logger) PasswordRouter {
+	return &passwordRouter{
+		con:          con,
+		auth:         auth,
+		log:          log,
+		ipLimiter:    security.NewRateLimiter(10, time.Hour),
+		emailLimiter: security.NewRateLimiter(3, time.Hour),
+	}
+}
+
+// RegisterRoutes will register password routes.
+func (p *passwordRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/password/forgot", p.forgotPassword)
+	router.Post("/password/reset", p.resetPassword)
+
+	p.log.Info().Msg("Password routes registered")
+}
+
+// forgotPassword will email a password reset token to the specified email. Response is the same whether
+// the email is registered or not.
+func (p *passwordRouter) forgotPassword(c *fiber.Ctx) error {
+	p.log.Info().Msg("========= forgotPassword route called =========")
+	user := models.User{}
+
+	err := c.BodyParser(&user)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	email := strings.TrimSpace(user.Email)
+
+	if !p.ipLimiter.Allow(c.IP()) || !p.emailLimiter.Allow(strings.ToLower(email)) {
+		p.log.Error().Str("ip", c.IP()).Msg("forgot password rate limit exceeded")
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error": "too many requests, please try again later",
+		})
+	}
+
+	err = p.con.ForgotPassword(email)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// resetPassword will set new password of the user with the reset token.
+func (p *passwordRouter) resetPassword(c *fiber.Ctx) error {
+	p.log.Info().Msg("========= resetPassword route called =========")
+	reset := models.PasswordReset{}
+
+	err := c.BodyParser(&reset)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = p.con.ResetPassword(&reset)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+)
+
+type PlaceholderRouter interface {
+	RegisterRoutes(router fiber.Router)
+	add(c *fiber.Ctx) error
+	claim(c *fiber.Ctx) error
+	getClaimable(c *fiber.Ctx) error
+}
+
+type placeholderRouter struct {
+	con  controllers.PlaceholderController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewPlaceholderRouter will create new instance of PlaceholderRouter.
+func NewPlaceholderRouter(con controllers.PlaceholderController, auth security.Authentication, log zerolog.Logger) PlaceholderRouter {
+	return &placeholderRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register routes for placeholder router.
+func (p *placeholderRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/group/:groupId<uuid>/placeholder", p.auth.MandatoryAuthMiddleware, p.auth.GroupMemberMiddleware, p.add)
+	router.Post("/placeholder/:placeholderId<uuid>/claim", p.auth.MandatoryAuthMiddleware, p.claim)
+	router.Get("/user/:userId<uuid>/placeholders", p.auth.MandatoryAuthMiddleware, p.auth.SelfMiddleware, p.getClaimable)
+	p.log.Info().Msg("Placeholder routes registered")
+}
+
+// add will add a name-only member to specified group.
+func (p *placeholderRouter) add(c *fiber.Ctx) error {
+	p.log.Info().Msg("========= add placeholder route called =========")
+	placeholder := models.User{}
+
+	err := c.BodyParser(&placeholder)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = p.con.Add(&placeholder, groupId, user.Id)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(placeholder.Id)
+}
+
+// claim will replace specified placeholder with the logged in user.
+func (p *placeholderRouter) claim(c *fiber.Ctx) error {
+	p.log.Info().Msg("========= claim placeholder route called =========")
+
+	placeholderId, err := uuid.Parse(c.Params("placeholderId"))
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = p.con.Claim(placeholderId, user.Id)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// getClaimable will fetch placeholders which can be claimed by specified user.
+func (p *placeholderRouter) getClaimable(c *fiber.Ctx) error {
+	p.log.Info().Msg("========= getClaimable route called =========")
+	placeholders := []models.UserGroupDTO{}
+
+	userId, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = p.con.GetClaimable(&placeholders, userId)
+	if err != nil {
+		p.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(placeholders)
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+)
+
+type SessionRouter interface {
+	RegisterRoutes(router fiber.Router)
+	refresh(c *fiber.Ctx) error
+	logout(c *fiber.Ctx) error
+	getSessions(c *fiber.Ctx) error
+	revokeSession(c *fiber.Ctx) error
+	revokeOtherSessions(c *fiber.Ctx) error
+}
+
+type sessionRouter struct {
+	con  controllers.SessionController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewSessionRouter will create new instance of SessionRouter.
+func NewSessionRouter(con controllers.SessionController, auth security.Authentication, log zerolog.Logger) SessionRouter {
+	return &sessionRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register session routes.
+func (s *sessionRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/token/refresh", s.refresh)
+	router.Get("/logout", s.auth.MandatoryAuthMiddleware, s.logout)
+	router.Get("/me/sessions", s.auth.MandatoryAuthMiddleware, s.getSessions)
+	router.Delete("/me/sessions/:sessionId<uuid>", s.auth.MandatoryAuthMiddleware, s.revokeSession)
+	router.Delete("/me/sessions", s.auth.MandatoryAuthMiddleware, s.revokeOtherSessions)
+
+	s.log.Info().Msg("Session routes registered")
+}
+
+// refresh will issue new tokens for the session of specified refresh token.
+func (s *sessionRouter) refresh(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= refresh route called =========")
+	tokens := models.AuthTokens{}
+
+	err := c.BodyParser(&tokens)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	refreshToken := tokens.RefreshToken
+	tokens = models.AuthTokens{}
+
+	err = s.con.Refresh(refreshToken, &tokens)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     "authorization",
+		Value:    tokens.AccessToken,
+		HTTPOnly: false,
+		Secure:   true,
+	})
+
+	return c.Status(http.StatusOK).JSON(tokens)
+}
+
+// logout will revoke the session of the logged in user.
+func (s *sessionRouter) logout(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= Logout route called =========")
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+	sessionId := c.Locals("sessionId").(uuid.UUID)
+
+	err := s.con.Revoke(sessionId, user.Id)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.ClearCookie("authorization")
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "user successfully logged out",
+	})
+}
+
+// getSessions will fetch the devices where the logged in user is logged in.
+func (s *sessionRouter) getSessions(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= getSessions route called =========")
+	sessions := []models.SessionDTO{}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+	sessionId := c.Locals("sessionId").(uuid.UUID)
+
+	err := s.con.GetSessions(&sessions, user.Id, sessionId)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(sessions)
+}
+
+// revokeSession will log the logged in user out of specified session.
+func (s *sessionRouter) revokeSession(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= revokeSession route called =========")
+
+	sessionId, err := uuid.Parse(c.Params("sessionId"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = s.con.Revoke(sessionId, user.Id)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// revokeOtherSessions will log the logged in user out everywhere except the session of the request.
+func (s *sessionRouter) revokeOtherSessions(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= revokeOtherSessions route called =========")
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+	sessionId := c.Locals("sessionId").(uuid.UUID)
+
+	err := s.con.RevokeOthers(sessionId, user.Id)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// newSession will create the session of the device which sent the request.
+func newSession(c *fiber.Ctx) models.Session {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	return models.Session{
+		UserAgent: userAgent,
+		IPAddress: c.IP(),
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/controllers"
+	"github.com/shaileshhb/equisplit/src/models"
+	"github.com/shaileshhb/equisplit/src/security"
+	"github.com/shaileshhb/equisplit/src/util"
+)
+
+type SettlementRouter interface {
+	RegisterRoutes(router fiber.Router)
+	add(c *fiber.Ctx) error
+	confirm(c *fiber.Ctx) error
+	reject(c *fiber.Ctx) error
+	delete(c *fiber.Ctx) error
+	getGroupSettlements(c *fiber.Ctx) error
+}
+
+type settlementRouter struct {
+	con  controllers.SettlementController
+	auth security.Authentication
+	log  zerolog.Logger
+}
+
+// NewSettlementRouter will create new instance of SettlementRouter.
+func NewSettlementRouter(con controllers.SettlementController, auth security.Authentication, log zerolog.Logger) SettlementRouter {
+	return &settlementRouter{
+		con:  con,
+		auth: auth,
+		log:  log,
+	}
+}
+
+// RegisterRoutes will register routes for settlement router.
+func (s *settlementRouter) RegisterRoutes(router fiber.Router) {
+	router.Post("/group/:groupId<uuid>/settlement", s.auth.MandatoryAuthMiddleware, s.auth.GroupMemberMiddleware, s.add)
+	router.Get("/group/:groupId<uuid>/settlements", s.auth.MandatoryAuthMiddleware, s.auth.GroupMemberMiddleware, s.getGroupSettlements)
+	router.Put("/settlement/:settlementId<uuid>/confirm", s.auth.MandatoryAuthMiddleware, s.auth.GroupMemberMiddleware, s.confirm)
+	router.Put("/settlement/:settlementId<uuid>/reject", s.auth.MandatoryAuthMiddleware, s.auth.GroupMemberMiddleware, s.reject)
+	router.Delete("/settlement/:settlementId<uuid>", s.auth.MandatoryAuthMiddleware, s.auth.GroupMemberMiddleware, s.delete)
+	s.log.Info().Msg("Settlement routes registered")
+}
+
+// add will record a payment between two members of specified group. Payment recorded by the member
+// who paid is pending until the receiver confirms it.
+func (s *settlementRouter) add(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= add settlement route called =========")
+	input := models.SettlementInput{}
+
+	err := c.BodyParser(&input)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	settlement := input.Settlement(user.Id, groupId)
+	if settlement.FromUserId == uuid.Nil {
+		settlement.FromUserId = user.Id
+	}
+
+	if settlement.FromUserId != user.Id && settlement.ToUserId != user.Id {
+		err = errors.New("settlement can only be recorded by one of its members")
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = settlement.Validate()
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = s.con.Add(&settlement)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(settlement)
+}
+
+// confirm will confirm that the payment of specified settlement was received.
+func (s *settlementRouter) confirm(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= confirm settlement route called =========")
+
+	settlementId, err := uuid.Parse(c.Params("settlementId"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = s.con.Confirm(user.Id, settlementId)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// reject will reject specified settlement with the reason specified in the body.
+func (s *settlementRouter) reject(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= reject settlement route called =========")
+	body := struct {
+		Reason string `json:"reason"`
+	}{}
+
+	err := c.BodyParser(&body)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	settlementId, err := uuid.Parse(c.Params("settlementId"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = s.con.Reject(user.Id, settlementId, body.Reason)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// delete will delete specified pending settlement.
+func (s *settlementRouter) delete(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= delete settlement route called =========")
+
+	settlementId, err := uuid.Parse(c.Params("settlementId"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = s.con.Delete(user.Id, settlementId)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// getGroupSettlements will fetch all settlements of specified group.
+func (s *settlementRouter) getGroupSettlements(c *fiber.Ctx) error {
+	s.log.Info().Msg("========= getGroupSettlements route called =========")
+	settlements := []models.SettlementDTO{}
+	parser := util.NewParser(c)
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var totalCount int64
+
+	err = s.con.GetGroupSettlements(&settlements, groupId, &totalCount, parser)
+	if err != nil {
+		s.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Response().Header.Add("X-Total-Count", strconv.Itoa(int(totalCount)))
+
+	return c.Status(http.StatusOK).JSON(settlements)
+}
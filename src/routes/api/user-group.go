@@ -15,6 +15,8 @@ type UserGroupRouter interface {
 	RegisterRoutes(router fiber.Router)
 	addUserToGroup(c *fiber.Ctx) error
 	deleteUserFromGroup(c *fiber.Ctx) error
+	updateRole(c *fiber.Ctx) error
+	transferOwnership(c *fiber.Ctx) error
 	getGroupDetails(c *fiber.Ctx) error
 	getUserGroups(c *fiber.Ctx) error
 }
@@ -36,11 +38,14 @@ func NewUserGroupRouter(con controllers.UserGroupController, auth security.Authe
 
 // RegisterRoutes will register routes for user-group router.
 func (u *userGroupRouter) RegisterRoutes(router fiber.Router) {
-	router.Get("/group/:groupId<uuid>", u.auth.MandatoryAuthMiddleware, u.getGroupDetails)
-	router.Get("/user/:userId<uuid>/group", u.auth.MandatoryAuthMiddleware, u.getUserGroups)
-	router.Post("/group/:groupId<uuid>/user", u.auth.MandatoryAuthMiddleware, u.addUserToGroup)
-	router.Get("/group/:groupId<uuid>/users", u.auth.MandatoryAuthMiddleware, u.getGroupUsers)
-	router.Delete("/group/:groupId<uuid>/user/:userGroupId<uuid>", u.auth.MandatoryAuthMiddleware, u.deleteUserFromGroup)
+	router.Get("/group/:groupId<uuid>", u.auth.MandatoryAuthMiddleware, u.auth.GroupMemberMiddleware, u.getGroupDetails)
+	router.Get("/user/:userId<uuid>/group", u.auth.MandatoryAuthMiddleware, u.auth.SelfMiddleware, u.getUserGroups)
+	router.Post("/group/:groupId<uuid>/user", u.auth.MandatoryAuthMiddleware, u.auth.GroupMemberMiddleware, u.addUserToGroup)
+	router.Get("/group/:groupId<uuid>/users", u.auth.MandatoryAuthMiddleware, u.auth.GroupMemberMiddleware, u.getGroupUsers)
+	router.Delete("/group/:groupId<uuid>/user/:userGroupId<uuid>", u.auth.MandatoryAuthMiddleware, u.auth.GroupMemberMiddleware, u.deleteUserFromGroup)
+	router.Put("/group/:groupId<uuid>/user/:userGroupId<uuid>/role", u.auth.MandatoryAuthMiddleware, u.auth.GroupMemberMiddleware, u.updateRole)
+	router.Post("/group/:groupId<uuid>/transfer-ownership", u.auth.MandatoryAuthMiddleware, u.auth.GroupMemberMiddleware, u.transferOwnership)
+
 	u.log.Info().Msg("UserGroup routes registered")
 }
 
@@ -65,7 +70,10 @@ func (u *userGroupRouter) addUserToGroup(c *fiber.Ctx) error {
 		})
 	}
 
-	err = u.con.AddUserToGroup(&userGroup)
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = u.con.AddUserToGroup(&userGroup, user.Id)
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -91,7 +99,111 @@ func (u *userGroupRouter) deleteUserFromGroup(c *fiber.Ctx) error {
 
 	userGroup.Id = id
 
-	err = u.con.DeleteUserFromGroup(&userGroup)
+	// successor is required only when the owner leaves the group and resolution only when the
+	// member has outstanding balance.
+	options := models.DepartureOptions{
+		Resolution: models.DepartureResolution(c.Query("resolution")),
+	}
+
+	options.SuccessorId, err = parseOptionalUUID(c.Query("successorId"))
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	options.ReassignTo, err = parseOptionalUUID(c.Query("reassignTo"))
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = u.con.DeleteUserFromGroup(&userGroup, user.Id, &options)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// updateRole will change role of specified member of the group.
+func (u *userGroupRouter) updateRole(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= updateRole route called =========")
+	userGroup := models.UserGroup{}
+
+	err := c.BodyParser(&userGroup)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userGroup.Id, err = uuid.Parse(c.Params("userGroupId"))
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = u.con.UpdateRole(&userGroup, user.Id)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
+}
+
+// transferOwnership will make specified member the owner of the group.
+func (u *userGroupRouter) transferOwnership(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= transferOwnership route called =========")
+	body := struct {
+		UserId uuid.UUID `json:"userId"`
+	}{}
+
+	err := c.BodyParser(&body)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if body.UserId == uuid.Nil {
+		u.log.Error().Msg("user must be specified")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "user must be specified",
+		})
+	}
+
+	groupId, err := uuid.Parse(c.Params("groupId"))
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	err = u.con.TransferOwnership(groupId, body.UserId, user.Id)
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -176,3 +288,17 @@ func (u *userGroupRouter) getGroupUsers(c *fiber.Ctx) error {
 
 	return c.Status(http.StatusOK).JSON(userGroups)
 }
+
+// parseOptionalUUID will parse specified value if it is not empty.
+func parseOptionalUUID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
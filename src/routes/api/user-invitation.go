@@ -16,7 +16,9 @@ type UserInvitationRouter interface {
 	RegisterRoutes(router fiber.Router)
 	add(c *fiber.Ctx) error
 	acceptInvitation(c *fiber.Ctx) error
-	deleteInvitation(c *fiber.Ctx) error
+	declineInvitation(c *fiber.Ctx) error
+	revokeInvitation(c *fiber.Ctx) error
+	resendInvitation(c *fiber.Ctx) error
 	getGroupInvitation(c *fiber.Ctx) error
 }
 
@@ -38,9 +40,11 @@ func NewUserInvitationRouter(con controllers.UserInvitationController, auth secu
 // RegisterRoutes will register routes for user-group router.
 func (u *userInvitationRouter) RegisterRoutes(router fiber.Router) {
 	router.Post("/user-invitations", u.auth.MandatoryAuthMiddleware, u.add)
-	router.Put("/user-invitations/:userInvitationId<uint>", u.auth.MandatoryAuthMiddleware, u.acceptInvitation)
-	router.Delete("/user-invitations/:userInvitationId<uint>", u.auth.MandatoryAuthMiddleware, u.deleteInvitation)
-	router.Get("/groups/:groupId<uint>/user-invitations", u.auth.MandatoryAuthMiddleware, u.getGroupInvitation)
+	router.Put("/user-invitations/:userInvitationId<uuid>/accept", u.auth.MandatoryAuthMiddleware, u.acceptInvitation)
+	router.Put("/user-invitations/:userInvitationId<uuid>/decline", u.auth.MandatoryAuthMiddleware, u.declineInvitation)
+	router.Put("/user-invitations/:userInvitationId<uuid>/resend", u.auth.MandatoryAuthMiddleware, u.resendInvitation)
+	router.Delete("/user-invitations/:userInvitationId<uuid>", u.auth.MandatoryAuthMiddleware, u.revokeInvitation)
+	router.Get("/groups/:groupId<uuid>/user-invitations", u.auth.MandatoryAuthMiddleware, u.auth.GroupMemberMiddleware, u.getGroupInvitation)
 	router.Get("/user-invitations", u.auth.MandatoryAuthMiddleware, u.getInvitations)
 
 	u.log.Info().Msg("UserInvitation routes registered")
@@ -85,50 +89,31 @@ func (u *userInvitationRouter) add(c *fiber.Ctx) error {
 // acceptInvitation will mark invitation as accepted and add user in the group that they were invited to.
 func (u *userInvitationRouter) acceptInvitation(c *fiber.Ctx) error {
 	u.log.Info().Msg("========= acceptInvitation route called =========")
-	userInvitation := models.UserInvitation{}
-
-	err := c.BodyParser(&userInvitation)
-	if err != nil {
-		u.log.Error().Err(err).Msg("")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	userInterface := c.Locals("user")
-	user := userInterface.(*models.User)
-	if userInvitation.UserId != user.Id {
-		u.log.Error().Err(err).Msg("")
-		return c.Status(http.StatusForbidden).JSON(fiber.Map{
-			"error": "Invalid invitation specified",
-		})
-	}
-
-	userInvitation.Id, err = uuid.Parse(c.Params("userInvitationId"))
-	if err != nil {
-		u.log.Error().Err(err).Msg("")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
+	return u.respond(c, u.con.AcceptInvitation)
+}
 
-	err = u.con.AcceptInvitation(&userInvitation)
-	if err != nil {
-		u.log.Error().Err(err).Msg("")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
+// declineInvitation will mark invitation as declined.
+func (u *userInvitationRouter) declineInvitation(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= declineInvitation route called =========")
+	return u.respond(c, u.con.DeclineInvitation)
+}
 
-	return c.Status(http.StatusAccepted).JSON(nil)
+// revokeInvitation will mark invitation as revoked.
+func (u *userInvitationRouter) revokeInvitation(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= revokeInvitation route called =========")
+	return u.respond(c, u.con.RevokeInvitation)
 }
 
-// deleteInvitation will delete the specified invitation
-func (u *userInvitationRouter) deleteInvitation(c *fiber.Ctx) error {
-	u.log.Info().Msg("========= deleteInvitation route called =========")
-	userInvitation := models.UserInvitation{}
+// resendInvitation will make invitation pending again with a new expiry.
+func (u *userInvitationRouter) resendInvitation(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= resendInvitation route called =========")
+	return u.respond(c, u.con.ResendInvitation)
+}
 
-	err := c.BodyParser(&userInvitation)
+// respond will call specified action of the controller for the invitation in the route by the logged
+// in user.
+func (u *userInvitationRouter) respond(c *fiber.Ctx, action func(invitationId, userId uuid.UUID) error) error {
+	invitationId, err := uuid.Parse(c.Params("userInvitationId"))
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -136,15 +121,10 @@ func (u *userInvitationRouter) deleteInvitation(c *fiber.Ctx) error {
 		})
 	}
 
-	userInvitation.Id, err = uuid.Parse(c.Params("userInvitationId"))
-	if err != nil {
-		u.log.Error().Err(err).Msg("")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
 
-	err = u.con.DeleteInvitation(&userInvitation)
+	err = action(invitationId, user.Id)
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -158,7 +138,7 @@ func (u *userInvitationRouter) deleteInvitation(c *fiber.Ctx) error {
 // getGroupInvitation will fetch all invitations of specified group.
 func (u *userInvitationRouter) getGroupInvitation(c *fiber.Ctx) error {
 	u.log.Info().Msg("========= GetGroupInvitation route called =========")
-	userInvitation := []models.UserInvitation{}
+	userInvitation := []models.UserInvitationDTO{}
 
 	groupId, err := uuid.Parse(c.Params("groupId"))
 	if err != nil {
@@ -176,7 +156,7 @@ func (u *userInvitationRouter) getGroupInvitation(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.Status(http.StatusOK).JSON(nil)
+	return c.Status(http.StatusOK).JSON(userInvitation)
 }
 
 func (u *userInvitationRouter) getInvitations(c *fiber.Ctx) error {
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -16,7 +18,9 @@ type UserRouter interface {
 	RegisterRoutes(router fiber.Router)
 	register(ctx *fiber.Ctx) error
 	login(c *fiber.Ctx) error
-	logout(c *fiber.Ctx) error
+	loginMfa(c *fiber.Ctx) error
+	verifyEmail(c *fiber.Ctx) error
+	resendVerification(c *fiber.Ctx) error
 	getUser(c *fiber.Ctx) error
 	getUsers(c *fiber.Ctx) error
 }
@@ -25,14 +29,20 @@ type userRouter struct {
 	con  controllers.UserController
 	auth security.Authentication
 	log  zerolog.Logger
+	// verificationLimiter limits verification emails a user can ask for.
+	verificationLimiter *security.RateLimiter
+	// mfaLimiter limits second factor attempts from an IP.
+	mfaLimiter *security.RateLimiter
 }
 
 // NewUserRouter will create new instance for UserRouter
 func NewUserRouter(con controllers.UserController, auth security.Authentication, log zerolog.Logger) UserRouter {
 	return &userRouter{
-		con:  con,
-		auth: auth,
-		log:  log,
+		con:                 con,
+		auth:                auth,
+		log:                 log,
+		verificationLimiter: security.NewRateLimiter(3, time.Hour),
+		mfaLimiter:          security.NewRateLimiter(10, 5*time.Minute),
 	}
 }
 
@@ -40,8 +50,10 @@ func NewUserRouter(con controllers.UserController, auth security.Authentication,
 func (u *userRouter) RegisterRoutes(router fiber.Router) {
 	router.Post("/register", u.register)
 	router.Post("/login", u.login)
-	router.Get("/logout", u.logout)
-	router.Get("/users/:userId<int>", u.auth.MandatoryAuthMiddleware, u.getUser)
+	router.Post("/login/mfa", u.loginMfa)
+	router.Get("/verify-email/:token", u.verifyEmail)
+	router.Post("/verify-email/resend", u.auth.MandatoryAuthMiddleware, u.resendVerification)
+	router.Get("/users/:userId<uuid>", u.auth.MandatoryAuthMiddleware, u.getUser)
 	router.Get("/users", u.auth.MandatoryAuthMiddleware, u.getUsers)
 
 	// router.Get("/unlimited", u.auth.TokenBucketRateLimiter, u.unlimited)
@@ -58,6 +70,8 @@ func (u *userRouter) limited(c *fiber.Ctx) error {
 func (u *userRouter) register(c *fiber.Ctx) error {
 	u.log.Info().Msg("========= Register route called =========")
 	user := &models.User{}
+	tokens := models.AuthTokens{}
+	session := newSession(c)
 
 	err := c.BodyParser(user)
 	if err != nil {
@@ -67,7 +81,7 @@ func (u *userRouter) register(c *fiber.Ctx) error {
 		})
 	}
 
-	err = u.con.Register(user)
+	err = u.con.Register(user, &session, &tokens)
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -75,7 +89,33 @@ func (u *userRouter) register(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := security.GenerateJWT(user)
+	c.Cookie(&fiber.Cookie{
+		Name:     "authorization",
+		Value:    tokens.AccessToken,
+		HTTPOnly: false,
+		Secure:   true,
+	})
+
+	userResponse := map[string]interface{}{
+		"userId":       user.Id,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresOn":    tokens.ExpiresOn,
+		"name":         user.Name,
+		"email":        user.Email,
+		// email is verified with the link emailed to the user.
+		"emailVerified": user.EmailVerified,
+	}
+
+	return c.Status(http.StatusCreated).JSON(userResponse)
+}
+
+// verifyEmail will mark email of the user as verified and return the invitations they have received.
+func (u *userRouter) verifyEmail(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= verifyEmail route called =========")
+	invitations := []models.UserInvitationDTO{}
+
+	err := u.con.VerifyEmail(c.Params("token"), &invitations)
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -83,27 +123,44 @@ func (u *userRouter) register(c *fiber.Ctx) error {
 		})
 	}
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "authorization",
-		Value:    token,
-		HTTPOnly: false,
-		Secure:   true,
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"emailVerified": true,
+		// pending invitations sent to the email before the user registered.
+		"invitations": invitations,
 	})
+}
 
-	userResponse := map[string]interface{}{
-		"userId": user.Id,
-		"token":  token,
-		"name":   user.Name,
-		"email":  user.Email,
+// resendVerification will email a new verification link to the logged in user.
+func (u *userRouter) resendVerification(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= resendVerification route called =========")
+
+	userInterface := c.Locals("user")
+	user := userInterface.(*models.User)
+
+	if !u.verificationLimiter.Allow(user.Id.String()) {
+		u.log.Error().Msg("verification email rate limit exceeded")
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error": "too many requests, please try again later",
+		})
 	}
 
-	return c.Status(http.StatusCreated).JSON(userResponse)
+	err := u.con.ResendVerification(user.Id)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(nil)
 }
 
 // login will check user details and set the cookie
 func (u *userRouter) login(c *fiber.Ctx) error {
 	u.log.Info().Msg("========= Login route called =========")
 	user := &models.User{}
+	tokens := models.AuthTokens{}
+	session := newSession(c)
 
 	err := c.BodyParser(user)
 	if err != nil {
@@ -113,7 +170,13 @@ func (u *userRouter) login(c *fiber.Ctx) error {
 		})
 	}
 
-	err = u.con.Login(user)
+	err = u.con.Login(user, &session, &tokens)
+	if errors.Is(err, controllers.ErrTooManyLoginAttempts) {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -121,7 +184,64 @@ func (u *userRouter) login(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := security.GenerateJWT(user)
+	// user has to enter their second factor at /login/mfa with the mfa token.
+	if tokens.MfaToken != "" {
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"mfaRequired": true,
+			"mfaToken":    tokens.MfaToken,
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     "authorization",
+		Value:    tokens.AccessToken,
+		HTTPOnly: false,
+		Secure:   true,
+	})
+
+	userResponse := map[string]interface{}{
+		"userId":        user.Id,
+		"token":         tokens.AccessToken,
+		"refreshToken":  tokens.RefreshToken,
+		"expiresOn":     tokens.ExpiresOn,
+		"name":          user.Name,
+		"email":         user.Email,
+		"emailVerified": user.EmailVerified,
+	}
+
+	return c.Status(http.StatusOK).JSON(userResponse)
+}
+
+// loginMfa will check the second factor of the user and set the cookie.
+func (u *userRouter) loginMfa(c *fiber.Ctx) error {
+	u.log.Info().Msg("========= LoginMfa route called =========")
+	login := models.MfaLogin{}
+	user := &models.User{}
+	tokens := models.AuthTokens{}
+	session := newSession(c)
+
+	if !u.mfaLimiter.Allow(c.IP()) {
+		u.log.Error().Msg("mfa login rate limit exceeded")
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error": "too many requests, please try again later",
+		})
+	}
+
+	err := c.BodyParser(&login)
+	if err != nil {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = u.con.LoginMfa(&login, user, &session, &tokens)
+	if errors.Is(err, controllers.ErrTooManyLoginAttempts) {
+		u.log.Error().Err(err).Msg("")
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		u.log.Error().Err(err).Msg("")
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
@@ -131,16 +251,19 @@ func (u *userRouter) login(c *fiber.Ctx) error {
 
 	c.Cookie(&fiber.Cookie{
 		Name:     "authorization",
-		Value:    token,
+		Value:    tokens.AccessToken,
 		HTTPOnly: false,
 		Secure:   true,
 	})
 
 	userResponse := map[string]interface{}{
-		"userId": user.Id,
-		"token":  token,
-		"name":   user.Name,
-		"email":  user.Email,
+		"userId":        user.Id,
+		"token":         tokens.AccessToken,
+		"refreshToken":  tokens.RefreshToken,
+		"expiresOn":     tokens.ExpiresOn,
+		"name":          user.Name,
+		"email":         user.Email,
+		"emailVerified": user.EmailVerified,
 	}
 
 	return c.Status(http.StatusOK).JSON(userResponse)
@@ -172,15 +295,6 @@ func (u *userRouter) getUser(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(user)
 }
 
-// logout will log user out from the system
-func (u *userRouter) logout(c *fiber.Ctx) error {
-	u.log.Info().Msg("========= Logout route called =========")
-
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"message": "user successfully logged out",
-	})
-}
-
 // getUsers will fetch specified user details.
 func (u *userRouter) getUsers(c *fiber.Ctx) error {
 	u.log.Info().Msg("========= GetUsers route called =========")
@@ -0,0 +1,139 @@
+package security
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shaileshhb/equisplit/src/models"
+	"gorm.io/gorm"
+)
+
+// groupScopedParam is a route param which identifies a resource owned by a group.
+type groupScopedParam struct {
+	param string
+	model interface{}
+	table string
+	name  string
+}
+
+// groupScopedParams contains all route params which are resolved to the group owning the resource.
+var groupScopedParams = []groupScopedParam{
+	{param: "groupId", model: &models.Group{}, table: "groups", name: "group"},
+	{param: "transactionId", model: &models.GroupTransaction{}, table: "group_transactions", name: "transaction"},
+	{param: "userGroupId", model: &models.UserGroup{}, table: "user_groups", name: "user"},
+	{param: "expenseId", model: &models.Expense{}, table: "expenses", name: "expense"},
+	{param: "settlementId", model: &models.Settlement{}, table: "settlements", name: "settlement"},
+	{param: "inviteLinkId", model: &models.GroupInviteLink{}, table: "group_invite_links", name: "invite link"},
+}
+
+// errNotFound is returned when the resource specified in the route does not exist.
+type errNotFound struct {
+	name string
+}
+
+func (e errNotFound) Error() string {
+	return e.name + " not found"
+}
+
+// GroupMemberMiddleware will check that the logged in user is a member of the group owning the
+// resource specified in the route by :groupId, :transactionId, :userGroupId, :expenseId,
+// :settlementId or :inviteLinkId. It responds with 404 if the resource does not exist and 403 if
+// the user is not a member of its group. It must be used after MandatoryAuthMiddleware. Group id is
+// set in locals.
+func (a *Authentication) GroupMemberMiddleware(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*models.User)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	groupId, err := a.resolveGroup(c)
+	if err != nil {
+		a.log.Error().Err(err).Msg("")
+		if errors.As(err, &errNotFound{}) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	err = a.db.Where("user_groups.user_id = ? AND user_groups.group_id = ?", user.Id, groupId).
+		First(&models.UserGroup{}).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			a.log.Error().Msg("user is not a member of the group")
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Forbidden",
+			})
+		}
+		a.log.Error().Err(err).Msg("")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Locals("groupId", groupId)
+	return c.Next()
+}
+
+// resolveGroup will return the group owning all resources specified in the route. If the
+// resources belong to different groups, e.g. a user of another group, it is treated as not found.
+func (a *Authentication) resolveGroup(c *fiber.Ctx) (uuid.UUID, error) {
+	groupId := uuid.Nil
+
+	for _, p := range groupScopedParams {
+		value := c.Params(p.param)
+		if value == "" {
+			continue
+		}
+
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return uuid.Nil, errNotFound{name: p.name}
+		}
+
+		column := "group_id"
+		if p.param == "groupId" {
+			column = "id"
+		}
+
+		ownerIds := []uuid.UUID{}
+
+		err = a.db.Model(p.model).Where(p.table+".id = ?", id).Limit(1).
+			Pluck(p.table+"."+column, &ownerIds).Error
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		if len(ownerIds) == 0 || (groupId != uuid.Nil && ownerIds[0] != groupId) {
+			return uuid.Nil, errNotFound{name: p.name}
+		}
+
+		groupId = ownerIds[0]
+	}
+
+	if groupId == uuid.Nil {
+		return uuid.Nil, errNotFound{name: "group"}
+	}
+
+	return groupId, nil
+}
+
+// SelfMiddleware will check that :userId in the route is the logged in user, so that a user cannot
+// act on behalf of another user. It must be used after MandatoryAuthMiddleware.
+func (a *Authentication) SelfMiddleware(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || c.Params("userId") != user.Id.String() {
+		a.log.Error().Msg("user in the route is not the logged in user")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Forbidden",
+		})
+	}
+
+	return c.Next()
+}
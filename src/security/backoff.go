@@ -0,0 +1,113 @@
+package security
+
+import (
+	"sync"
+	"time"
+)
+
+// BackoffPolicy decides how long further attempts are refused after repeated failures, e.g. of a login.
+// The first Threshold-1 failures are free. Then the delay starts at Base and doubles with every
+// failure up to Max, and from LockoutAfter failures the key is locked for Lockout. Failures older
+// than Reset are forgotten.
+type BackoffPolicy struct {
+	Threshold    int
+	Base         time.Duration
+	Max          time.Duration
+	LockoutAfter int
+	Lockout      time.Duration
+	Reset        time.Duration
+}
+
+// Delay will return the duration for which attempts are refused after specified number of failures,
+// and whether it is a lockout.
+func (p BackoffPolicy) Delay(failures int) (time.Duration, bool) {
+	if failures >= p.LockoutAfter {
+		return p.Lockout, true
+	}
+
+	if failures < p.Threshold {
+		return 0, false
+	}
+
+	delay := p.Base
+	for i := p.Threshold; i < failures && delay < p.Max; i++ {
+		delay *= 2
+	}
+
+	if delay > p.Max {
+		delay = p.Max
+	}
+
+	return delay, false
+}
+
+// Throttle tracks failures of keys, e.g. IPs, and refuses attempts of a key as specified by its policy.
+// Failures are counted in memory, so the policy applies to each instance of the server.
+type Throttle struct {
+	mu          sync.Mutex
+	policy      BackoffPolicy
+	failures    map[string]*throttleState
+	nextCleanup time.Time
+}
+
+// throttleState is the number of failures of a key and the time until which it is refused.
+type throttleState struct {
+	count        int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// NewThrottle will create a throttle with specified policy.
+func NewThrottle(policy BackoffPolicy) *Throttle {
+	return &Throttle{
+		policy:   policy,
+		failures: make(map[string]*throttleState),
+	}
+}
+
+// Allow will check if an attempt can be made for specified key.
+func (t *Throttle) Allow(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.failures[key]
+	return !ok || !state.blockedUntil.After(time.Now())
+}
+
+// Fail will record a failure for specified key and return true if it locked the key.
+func (t *Throttle) Fail(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.cleanup(now)
+
+	state, ok := t.failures[key]
+	if !ok || now.Sub(state.lastFailure) >= t.policy.Reset {
+		state = &throttleState{}
+		t.failures[key] = state
+	}
+
+	state.count++
+	state.lastFailure = now
+
+	delay, locked := t.policy.Delay(state.count)
+	state.blockedUntil = now.Add(delay)
+
+	return locked
+}
+
+// cleanup will remove keys whose failures are forgotten, once every reset period, so that memory does
+// not grow with every key ever seen.
+func (t *Throttle) cleanup(now time.Time) {
+	if now.Before(t.nextCleanup) {
+		return
+	}
+
+	for key, state := range t.failures {
+		if now.Sub(state.lastFailure) >= t.policy.Reset && !state.blockedUntil.After(now) {
+			delete(t.failures, key)
+		}
+	}
+	t.nextCleanup = now.Add(t.policy.Reset)
+}
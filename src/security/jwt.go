@@ -1,47 +1,178 @@
 package security
 
 import (
-	"os"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"github.com/samber/lo"
 	"github.com/shaileshhb/equisplit/src/models"
 )
 
-// GenerateInviteJwt will create a JWT for the given group
-func GenerateInviteJwt(groupId uuid.UUID) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": groupId,
+const (
+	// inviteTokenType is the type claim of invite link tokens, so that they cannot be used for login.
+	inviteTokenType = "invite"
+	// verifyEmailTokenType is the type claim of email verification tokens.
+	verifyEmailTokenType = "verify-email"
+	// mfaTokenType is the type claim of tokens of users who have entered their password but not their
+	// second factor yet.
+	mfaTokenType = "mfa"
+)
+
+// signToken will sign the claims for specified audience with the active key of the keyring.
+func signToken(claims jwt.MapClaims, audience string) (string, error) {
+	if keyring == nil {
+		return "", errors.New("keyring is not initialized")
+	}
+	return keyring.sign(claims, audience)
+}
+
+// parseToken will verify specified token of the audience with the keyring and set its claims.
+func parseToken(t string, audience string, claims jwt.MapClaims, options ...jwt.ParserOption) error {
+	if keyring == nil {
+		return errors.New("keyring is not initialized")
+	}
+	return keyring.parse(t, audience, claims, options...)
+}
+
+// GenerateInviteJwt will create a JWT for the given invite link of a group which expires with the link.
+func GenerateInviteJwt(linkId, groupId uuid.UUID, expiresOn time.Time) (string, error) {
+	return signToken(jwt.MapClaims{
+		"sub": linkId,
+		"grp": groupId,
+		"typ": inviteTokenType,
+		"iat": jwt.NewNumericDate(time.Now()),
+		"exp": jwt.NewNumericDate(expiresOn),
+	}, audienceInvite)
+}
+
+// ValidateInviteJwt will validate specified invite token and return id of its invite link.
+func ValidateInviteJwt(t string) (uuid.UUID, error) {
+	claims := jwt.MapClaims{}
+	err := parseToken(t, audienceInvite, claims)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if claims["typ"] != inviteTokenType {
+		return uuid.Nil, errors.New("invalid invite token")
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(sub)
+}
+
+// GenerateVerificationJwt will create a JWT to verify current email of specified user. Token is no
+// longer valid if the email of the user changes.
+func GenerateVerificationJwt(user *models.User, expiresOn time.Time) (string, error) {
+	return signToken(jwt.MapClaims{
+		"sub":   user.Id,
+		"email": user.Email,
+		"typ":   verifyEmailTokenType,
+		"iat":   jwt.NewNumericDate(time.Now()),
+		"exp":   jwt.NewNumericDate(expiresOn),
+	}, audienceVerifyEmail)
+}
+
+// ValidateVerificationJwt will validate specified email verification token and return the user and
+// the email it verifies.
+func ValidateVerificationJwt(t string) (uuid.UUID, string, error) {
+	claims := jwt.MapClaims{}
+	err := parseToken(t, audienceVerifyEmail, claims)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	if claims["typ"] != verifyEmailTokenType {
+		return uuid.Nil, "", errors.New("invalid verification token")
+	}
+
+	email, ok := claims["email"].(string)
+	if !ok {
+		return uuid.Nil, "", errors.New("invalid verification token")
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	userId, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return userId, email, nil
+}
+
+// GenerateMfaJwt will create a JWT for the user who has entered their password and has to complete the
+// login with their second factor.
+func GenerateMfaJwt(userId uuid.UUID, expiresOn time.Time) (string, error) {
+	return signToken(jwt.MapClaims{
+		"sub": userId,
+		"typ": mfaTokenType,
 		"iat": jwt.NewNumericDate(time.Now()),
-		// "exp": jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * 7)), // 7 days
-	})
-	return token.SignedString([]byte(os.Getenv("JWT_KEY")))
+		"exp": jwt.NewNumericDate(expiresOn),
+	}, audienceMfa)
 }
 
-// GenerateJWT will generate a JWT token for user login
-func GenerateJWT(user *models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// ValidateMfaJwt will validate specified mfa pending token and return its user.
+func ValidateMfaJwt(t string) (uuid.UUID, error) {
+	claims := jwt.MapClaims{}
+	err := parseToken(t, audienceMfa, claims)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if claims["typ"] != mfaTokenType {
+		return uuid.Nil, errors.New("invalid mfa token")
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(sub)
+}
+
+// LoginClaims are the claims of a login token.
+type LoginClaims struct {
+	UserId    uuid.UUID
+	SessionId uuid.UUID
+	IssuedAt  time.Time
+}
+
+// GenerateJWT will generate a JWT token for the login session of the user. Session id is set as jti,
+// so that the token is no longer accepted once the session is revoked.
+func GenerateJWT(user *models.User, sessionId uuid.UUID, expiresOn time.Time) (string, error) {
+	return signToken(jwt.MapClaims{
 		"sub": user.Id,
+		"jti": sessionId,
 		"iat": jwt.NewNumericDate(time.Now()),
-		"exp": jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * 7)), // 7 days
-	})
-	return token.SignedString([]byte(os.Getenv("JWT_KEY")))
+		"exp": jwt.NewNumericDate(expiresOn),
+	}, audienceAuth)
 }
 
-func ValidateJWT(t string) (*models.User, error) {
+// ValidateJWT will validate specified login token and return its claims. It does not check if the
+// session is still active.
+func ValidateJWT(t string) (*LoginClaims, error) {
 	claims := jwt.MapClaims{}
-	_, err := jwt.ParseWithClaims(t, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_KEY")), nil
-	})
+	err := parseToken(t, audienceAuth, claims, jwt.WithIssuedAt())
 	if err != nil {
 		return nil, err
 	}
-	sub := lo.Must(claims.GetSubject())
-	exp := lo.Must(claims.GetExpirationTime())
-	if exp.Before(time.Now()) {
-		return nil, jwt.ErrTokenExpired
+	if _, ok := claims["typ"]; ok {
+		return nil, errors.New("invalid token type")
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return nil, err
 	}
 
 	userId, err := uuid.Parse(sub)
@@ -49,9 +180,24 @@ func ValidateJWT(t string) (*models.User, error) {
 		return nil, err
 	}
 
-	return &models.User{
-		Base: models.Base{
-			Id: userId,
-		},
+	jti, ok := claims["jti"].(string)
+	if !ok {
+		return nil, errors.New("token session not specified")
+	}
+
+	sessionId, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, err
+	}
+
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return nil, errors.New("token issue time not specified")
+	}
+
+	return &LoginClaims{
+		UserId:    userId,
+		SessionId: sessionId,
+		IssuedAt:  iat.Time,
 	}, nil
 }
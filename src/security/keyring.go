@@ -0,0 +1,364 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Audiences of the tokens, so that a token issued for one purpose cannot be used for another.
+const (
+	audienceAuth        = "equisplit-auth"
+	audienceInvite      = "equisplit-invite"
+	audienceVerifyEmail = "equisplit-verify-email"
+	audienceMfa         = "equisplit-mfa"
+)
+
+// keyConfig is a key in the keyring file specified by JWT_KEYRING_FILE, e.g.
+//
+//	[{"kid": "2026-10", "alg": "EdDSA", "keyFile": "keys/2026-10.pem", "activeFrom": "2026-10-01T00:00:00Z"},
+//	 {"kid": "2026-07", "alg": "RS256", "keyFile": "keys/2026-07.pem", "activeFrom": "2026-07-01T00:00:00Z",
+//	  "retiresOn": "2026-11-15T00:00:00Z"}]
+//
+// Key file is a PEM private key for RS256 and EdDSA and the secret for HS256. Relative paths are
+// resolved from the directory of the keyring file.
+type keyConfig struct {
+	Kid        string     `json:"kid"`
+	Algorithm  string     `json:"alg"`
+	KeyFile    string     `json:"keyFile"`
+	ActiveFrom time.Time  `json:"activeFrom"`
+	RetiresOn  *time.Time `json:"retiresOn"`
+}
+
+// signingKey is a key of the keyring. New tokens are signed with the key which became active most
+// recently, and every key verifies tokens until it retires.
+type signingKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	signKey    interface{}
+	verifyKey  interface{}
+	activeFrom time.Time
+	retiresOn  *time.Time
+}
+
+// isRetired will check if the key can no longer verify tokens at specified time.
+func (k *signingKey) isRetired(now time.Time) bool {
+	return k.retiresOn != nil && !k.retiresOn.After(now)
+}
+
+// Keyring holds the keys which sign and verify tokens. Keys are rotated by adding a key whose
+// activeFrom is in the future. It is published in the JWKS as soon as it is loaded, becomes the
+// signing key at activeFrom, and the previous key keeps verifying the tokens it signed until its
+// retiresOn, which should be at least the lifetime of the longest token after the new key is active.
+type Keyring struct {
+	keys []*signingKey
+	// legacy verifies tokens which were signed with JWT_KEY before the keyring was introduced, and so
+	// do not have a kid.
+	legacy *signingKey
+}
+
+// keyring is the keyring used by the token functions. It is set by InitKeyring.
+var keyring *Keyring
+
+// InitKeyring will load the keyring from JWT_KEYRING_FILE. If it is not specified, JWT_KEY is the
+// only key and it signs with HS256. If both are specified, JWT_KEY only verifies old tokens.
+func InitKeyring() error {
+	secret := os.Getenv("JWT_KEY")
+	path := os.Getenv("JWT_KEYRING_FILE")
+
+	k := &Keyring{}
+
+	if secret != "" {
+		k.legacy = &signingKey{
+			method:    jwt.SigningMethodHS256,
+			signKey:   []byte(secret),
+			verifyKey: []byte(secret),
+		}
+	}
+
+	if path == "" {
+		if k.legacy == nil {
+			return errors.New("JWT_KEYRING_FILE or JWT_KEY must be specified")
+		}
+		k.keys = append(k.keys, k.legacy)
+		keyring = k
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	configs := []keyConfig{}
+
+	err = json.Unmarshal(content, &configs)
+	if err != nil {
+		return fmt.Errorf("invalid keyring file: %w", err)
+	}
+
+	for _, config := range configs {
+		key, err := loadKey(config, filepath.Dir(path))
+		if err != nil {
+			return fmt.Errorf("key %s: %w", config.Kid, err)
+		}
+		k.keys = append(k.keys, key)
+	}
+
+	_, err = k.activeKey(time.Now())
+	if err != nil {
+		return err
+	}
+
+	keyring = k
+	return nil
+}
+
+// loadKey will read the key file of specified key.
+func loadKey(config keyConfig, dir string) (*signingKey, error) {
+	if config.Kid == "" {
+		return nil, errors.New("kid must be specified")
+	}
+
+	if config.ActiveFrom.IsZero() {
+		return nil, errors.New("activeFrom must be specified")
+	}
+
+	if config.RetiresOn != nil && !config.RetiresOn.After(config.ActiveFrom) {
+		return nil, errors.New("retiresOn must be after activeFrom")
+	}
+
+	path := config.KeyFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		kid:        config.Kid,
+		activeFrom: config.ActiveFrom,
+		retiresOn:  config.RetiresOn,
+	}
+
+	switch config.Algorithm {
+	case "HS256":
+		secret := []byte(strings.TrimSpace(string(content)))
+		if len(secret) < 32 {
+			return nil, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = secret
+		key.verifyKey = secret
+
+	case "RS256":
+		private, err := parsePrivateKey(content)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := private.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("RS256 key must be an RSA private key")
+		}
+		key.method = jwt.SigningMethodRS256
+		key.signKey = rsaKey
+		key.verifyKey = &rsaKey.PublicKey
+
+	case "EdDSA":
+		private, err := parsePrivateKey(content)
+		if err != nil {
+			return nil, err
+		}
+		edKey, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("EdDSA key must be an Ed25519 private key")
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.signKey = edKey
+		key.verifyKey = edKey.Public()
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s, it must be HS256, RS256 or EdDSA", config.Algorithm)
+	}
+
+	return key, nil
+}
+
+// parsePrivateKey will parse a PEM encoded PKCS#8 or PKCS#1 private key.
+func parsePrivateKey(content []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("key file must be PEM encoded")
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// activeKey will return the key which signs tokens at specified time.
+func (k *Keyring) activeKey(now time.Time) (*signingKey, error) {
+	var active *signingKey
+
+	for _, key := range k.keys {
+		if key.activeFrom.After(now) || key.isRetired(now) {
+			continue
+		}
+		if active == nil || key.activeFrom.After(active.activeFrom) {
+			active = key
+		}
+	}
+
+	if active == nil {
+		return nil, errors.New("no active signing key in the keyring")
+	}
+
+	return active, nil
+}
+
+// sign will sign the claims for specified audience with the active key.
+func (k *Keyring) sign(claims jwt.MapClaims, audience string) (string, error) {
+	key, err := k.activeKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	claims["aud"] = audience
+
+	token := jwt.NewWithClaims(key.method, claims)
+	if key.kid != "" {
+		token.Header["kid"] = key.kid
+	}
+
+	return token.SignedString(key.signKey)
+}
+
+// parse will verify specified token of the audience with the key of its kid and set its claims.
+// Tokens without kid were signed before the keyring was introduced and do not have an audience.
+func (k *Keyring) parse(t string, audience string, claims jwt.MapClaims, options ...jwt.ParserOption) error {
+	var key *signingKey
+
+	_, err := jwt.ParseWithClaims(t, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key = k.verifyingKey(kid)
+		if key == nil {
+			return nil, errors.New("unknown signing key")
+		}
+
+		// algorithm must be that of the key, so that a public key cannot be used as an HMAC secret.
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("unexpected signing algorithm")
+		}
+
+		return key.verifyKey, nil
+	}, append(options, jwt.WithExpirationRequired())...)
+	if err != nil {
+		return err
+	}
+
+	if key.kid == "" && claims["aud"] == nil {
+		return nil
+	}
+
+	audiences, err := claims.GetAudience()
+	if err != nil {
+		return err
+	}
+
+	for _, aud := range audiences {
+		if aud == audience {
+			return nil
+		}
+	}
+
+	return errors.New("invalid token audience")
+}
+
+// verifyingKey will return the key with specified kid if it has not retired.
+func (k *Keyring) verifyingKey(kid string) *signingKey {
+	if kid == "" {
+		return k.legacy
+	}
+
+	now := time.Now()
+
+	for _, key := range k.keys {
+		if key.kid == kid && !key.isRetired(now) {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// JWK is a public key in a JSON Web Key Set.
+type JWK struct {
+	Kid       string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS will return public keys of the keyring which have not retired, including keys which will
+// become active later, so that clients can cache them before they are used. HS256 keys are secret
+// and are never published.
+func JWKS() []JWK {
+	keys := []JWK{}
+	if keyring == nil {
+		return keys
+	}
+
+	now := time.Now()
+	encoding := base64.RawURLEncoding
+
+	for _, key := range keyring.keys {
+		if key.isRetired(now) {
+			continue
+		}
+
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				Kid:       key.kid,
+				KeyType:   "RSA",
+				Algorithm: key.method.Alg(),
+				Use:       "sig",
+				N:         encoding.EncodeToString(public.N.Bytes()),
+				E:         encoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				Kid:       key.kid,
+				KeyType:   "OKP",
+				Algorithm: key.method.Alg(),
+				Use:       "sig",
+				Curve:     "Ed25519",
+				X:         encoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return keys
+}
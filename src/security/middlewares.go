@@ -1,23 +1,30 @@
 package security
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog"
+	"github.com/shaileshhb/equisplit/src/models"
+	"gorm.io/gorm"
 )
 
 type Authentication struct {
 	// rdb                     *redis.Client
+	db                      *gorm.DB
 	log                     zerolog.Logger
 	authorizationTypeBearer string
 }
 
-func NewAuthentication(log zerolog.Logger) Authentication {
+func NewAuthentication(db *gorm.DB, log zerolog.Logger) Authentication {
 	return Authentication{
 		// rdb:                     rdb,
+		db:                      db,
 		log:                     log,
 		authorizationTypeBearer: "bearer",
 	}
@@ -52,14 +59,18 @@ func (a *Authentication) MandatoryAuthMiddleware(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := ValidateJWT(fields[1])
+	claims, err := ValidateJWT(fields[1])
+	if err == nil {
+		err = a.checkSession(claims)
+	}
 	if err != nil {
 		a.log.Error().Err(err).Msg("")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Unauthorized",
 		})
 	}
-	c.Locals("user", user)
+	c.Locals("user", &models.User{Base: models.Base{Id: claims.UserId}})
+	c.Locals("sessionId", claims.SessionId)
 	return c.Next()
 }
 
@@ -88,14 +99,62 @@ func (a *Authentication) OptionalAuthMiddleware(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := ValidateJWT(authHeader)
+	claims, err := ValidateJWT(fields[1])
+	if err == nil {
+		err = a.checkSession(claims)
+	}
 	if err != nil {
 		a.log.Error().Err(err).Msg("")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Unauthorized",
 		})
 	}
-	c.Locals("user", user)
+	c.Locals("user", &models.User{Base: models.Base{Id: claims.UserId}})
+	c.Locals("sessionId", claims.SessionId)
+	return c.Next()
+}
+
+// checkSession will check that the session of the token is still active, so that tokens can no
+// longer be used once the user logs out or the session is revoked. Last seen time of the session is
+// updated at most once every SessionTouchInterval, so that every request does not write to it.
+func (a *Authentication) checkSession(claims *LoginClaims) error {
+	session := models.Session{}
+
+	err := a.db.Where("sessions.id = ? AND sessions.user_id = ?", claims.SessionId, claims.UserId).
+		First(&session).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("session not found")
+		}
+		return err
+	}
+
+	if !session.IsActive() {
+		return errors.New("session is revoked")
+	}
+
+	if session.LastSeenAt == nil || time.Since(*session.LastSeenAt) >= models.SessionTouchInterval {
+		err = a.db.Model(&models.Session{}).Where("sessions.id = ?", session.Id).
+			Update("LastSeenAt", time.Now()).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AdminMiddleware will check that the admin key specified in ADMIN_API_KEY is sent in X-Admin-Key header.
+func (a *Authentication) AdminMiddleware(c *fiber.Ctx) error {
+	adminKey := os.Getenv("ADMIN_API_KEY")
+
+	if adminKey == "" || subtle.ConstantTimeCompare([]byte(c.Get("X-Admin-Key")), []byte(adminKey)) != 1 {
+		a.log.Error().Msg("invalid admin key specified")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Forbidden",
+		})
+	}
+
 	return c.Next()
 }
 
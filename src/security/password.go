@@ -1,6 +1,14 @@
 package security
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 func HashPassword(password string) ([]byte, error) {
 	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -9,3 +17,40 @@ func HashPassword(password string) ([]byte, error) {
 func ComparePassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// GenerateToken will create a random URL safe token which is sent to the user, and its hash which
+// is stored.
+func GenerateToken() (string, string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken will return the hash of specified token which is stored in place of the token. Tokens
+// are random, so a fast hash is enough.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// dummyPassword is the hash compared when the user does not exist. It is created once when needed.
+var (
+	dummyPassword     []byte
+	dummyPasswordOnce sync.Once
+)
+
+// SimulatePasswordCheck will take as long as ComparePassword, so that the response time of a login
+// does not tell if the email is registered.
+func SimulatePasswordCheck(password string) {
+	dummyPasswordOnce.Do(func() {
+		dummyPassword, _ = HashPassword("equisplit-dummy-password")
+	})
+
+	_ = bcrypt.CompareHashAndPassword(dummyPassword, []byte(password))
+}
@@ -1,5 +1,68 @@
 package security
 
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows a fixed number of attempts for a key, e.g. an IP or an email, in every window.
+// Attempts are counted in memory, so the limit applies to each instance of the server.
+type RateLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	attempts    map[string]*rateWindow
+	nextCleanup time.Time
+}
+
+// rateWindow is the number of attempts made for a key in the window which started at start.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter will create a limiter which allows limit attempts for a key in every window.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:    limit,
+		window:   window,
+		attempts: make(map[string]*rateWindow),
+	}
+}
+
+// Allow will record an attempt for specified key and check if it is within the limit.
+func (r *RateLimiter) Allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.cleanup(now)
+
+	attempt, ok := r.attempts[key]
+	if !ok || now.Sub(attempt.start) >= r.window {
+		attempt = &rateWindow{start: now}
+		r.attempts[key] = attempt
+	}
+
+	attempt.count++
+	return attempt.count <= r.limit
+}
+
+// cleanup will remove keys whose window has ended, once every window, so that memory does not grow
+// with every key ever seen.
+func (r *RateLimiter) cleanup(now time.Time) {
+	if now.Before(r.nextCleanup) {
+		return
+	}
+
+	for key, attempt := range r.attempts {
+		if now.Sub(attempt.start) >= r.window {
+			delete(r.attempts, key)
+		}
+	}
+	r.nextCleanup = now.Add(r.window)
+}
+
 // TokenBucketRateLimiter is a rate limiter implementation using token bucket algorithm.
 // Here the bucket get refilled with X number of API_QUOTA. If Bucket becomes empty 429 error is thrown.
 // func (a *Authentication) TokenBucketRateLimiter(c *fiber.Ctx) error {
@@ -0,0 +1,125 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// totpStep is the duration for which a TOTP code is valid.
+	totpStep = 30
+	// totpDigits is the number of digits of a TOTP code.
+	totpDigits = 6
+	// totpSkew is the number of steps before and after the current step whose codes are accepted, so
+	// that small clock differences of the device do not fail the login.
+	totpSkew = 1
+)
+
+// totpEncoding is the base32 encoding of TOTP secrets which authenticator apps expect.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret will create a random base32 encoded secret for TOTP.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI will create the otpauth URI of specified secret which authenticator apps can scan.
+func TOTPURI(secret, issuer, account string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(totpDigits))
+	values.Set("period", fmt.Sprint(totpStep))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// TOTPCode will compute the code of specified secret for the counter as defined in RFC 4226 and RFC 6238.
+func TOTPCode(secret string, counter int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// TOTPCounter will return the TOTP counter at specified time.
+func TOTPCounter(now time.Time) int64 {
+	return now.Unix() / totpStep
+}
+
+// ValidateTOTP will check specified code against the secret at the time and return the counter it
+// matched. Codes of counters up to lastCounter are rejected, so that a code cannot be used twice.
+func ValidateTOTP(secret, code string, now time.Time, lastCounter int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := TOTPCounter(now)
+
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+
+		expected, err := TOTPCode(secret, counter)
+		if err != nil {
+			return 0, false
+		}
+
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCode will create a random one-time recovery code, e.g. ABCDE-FGHIJ, and its hash
+// which is stored.
+func GenerateRecoveryCode() (string, string, error) {
+	b := make([]byte, 7)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", err
+	}
+
+	code := totpEncoding.EncodeToString(b)[:10]
+	return code[:5] + "-" + code[5:], HashRecoveryCode(code), nil
+}
+
+// HashRecoveryCode will return the hash of specified recovery code. Case, spaces and dashes are
+// ignored so that the code can be typed as it is shown.
+func HashRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashToken(code)
+}
@@ -0,0 +1,272 @@
+package security
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 secret "12345678901234567890" of the RFC 6238 test vectors, base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestTOTPCodeRFC6238 checks the SHA1 test vectors of RFC 6238 appendix B. Vectors have 8 digits,
+// so the codes are their last 6 digits.
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		counter := TOTPCounter(time.Unix(tt.unix, 0))
+
+		code, err := TOTPCode(rfcSecret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, code, tt.want)
+		}
+
+		counter, ok := ValidateTOTP(rfcSecret, tt.want, time.Unix(tt.unix, 0), 0)
+		if !ok || counter != tt.unix/30 {
+			t.Errorf("code at %d was not accepted, got counter %d", tt.unix, counter)
+		}
+	}
+}
+
+func TestTOTPCodeLowercaseSecret(t *testing.T) {
+	code, err := TOTPCode(strings.ToLower(rfcSecret), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != "287082" {
+		t.Errorf("got %s, want 287082", code)
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	_, err := TOTPCode("not-base32!", 1)
+	if err == nil {
+		t.Fatal("expected error for invalid secret")
+	}
+
+	_, ok := ValidateTOTP("not-base32!", "287082", time.Unix(59, 0), 0)
+	if ok {
+		t.Fatal("code of invalid secret was accepted")
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPCounter(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		want   bool
+	}{
+		{name: "current step", offset: 0, want: true},
+		{name: "previous step", offset: -1, want: true},
+		{name: "next step", offset: 1, want: true},
+		{name: "two steps before", offset: -2, want: false},
+		{name: "two steps after", offset: 2, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := TOTPCode(rfcSecret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			counter, ok := ValidateTOTP(rfcSecret, code, now, 0)
+			if ok != tt.want {
+				t.Fatalf("accepted = %v, want %v", ok, tt.want)
+			}
+
+			if ok && counter != current+tt.offset {
+				t.Errorf("counter = %d, want %d", counter, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPCounter(now)
+
+	code, err := TOTPCode(rfcSecret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, ok := ValidateTOTP(rfcSecret, code, now, 0)
+	if !ok {
+		t.Fatal("code was not accepted")
+	}
+
+	// code is rejected once its counter is the last one used, even within the window.
+	_, ok = ValidateTOTP(rfcSecret, code, now, counter)
+	if ok {
+		t.Fatal("code was accepted twice")
+	}
+
+	_, ok = ValidateTOTP(rfcSecret, code, now.Add(30*time.Second), counter)
+	if ok {
+		t.Fatal("code was accepted twice in the next step")
+	}
+
+	// code of an earlier step is rejected after a later code is used.
+	previous, err := TOTPCode(rfcSecret, current-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok = ValidateTOTP(rfcSecret, previous, now, counter)
+	if ok {
+		t.Fatal("code older than the last used code was accepted")
+	}
+
+	// code of the next step can still be used.
+	next, err := TOTPCode(rfcSecret, current+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, ok = ValidateTOTP(rfcSecret, next, now, counter)
+	if !ok || counter != current+1 {
+		t.Fatalf("code of next step was not accepted, got counter %d", counter)
+	}
+}
+
+func TestValidateTOTPFormat(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{name: "surrounding spaces", code: " 287082 ", want: true},
+		{name: "wrong code", code: "287083", want: false},
+		{name: "too short", code: "28708", want: false},
+		{name: "too long", code: "2870820", want: false},
+		{name: "empty", code: "", want: false},
+		{name: "8 digit code", code: "94287082", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := ValidateTOTP(rfcSecret, tt.code, now, 0)
+			if ok != tt.want {
+				t.Errorf("accepted = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20 bytes are 32 base32 characters without padding.
+	if len(secret) != 32 {
+		t.Errorf("secret has %d characters, want 32", len(secret))
+	}
+
+	other, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret == other {
+		t.Error("secrets are not random")
+	}
+
+	_, err = TOTPCode(secret, 1)
+	if err != nil {
+		t.Errorf("generated secret cannot be used: %v", err)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI(rfcSecret, "EquiSplit", "john doe@example.com")
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("unexpected uri %s", uri)
+	}
+
+	if parsed.Path != "/EquiSplit:john doe@example.com" {
+		t.Errorf("label = %s", parsed.Path)
+	}
+
+	query := parsed.Query()
+	want := map[string]string{
+		"secret":    rfcSecret,
+		"issuer":    "EquiSplit",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+
+	for key, value := range want {
+		if query.Get(key) != value {
+			t.Errorf("%s = %s, want %s", key, query.Get(key), value)
+		}
+	}
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, hash, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("unexpected code format %s", code)
+	}
+
+	if HashRecoveryCode(code) != hash {
+		t.Error("hash of the code does not match")
+	}
+
+	// code can be typed without dash, in lowercase or with spaces.
+	typed := []string{
+		strings.ReplaceAll(code, "-", ""),
+		strings.ToLower(code),
+		" " + strings.ReplaceAll(code, "-", " ") + " ",
+	}
+
+	for _, c := range typed {
+		if HashRecoveryCode(c) != hash {
+			t.Errorf("hash of %q does not match", c)
+		}
+	}
+
+	other, _, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if HashRecoveryCode(other) == hash {
+		t.Error("recovery codes are not random")
+	}
+}
@@ -8,7 +8,7 @@ import (
 func (ser *Server) CreateRouterInstance() {
 	ser.InitializeRouter()
 
-	usercon := controllers.NewUserController(ser.DB)
+	usercon := controllers.NewUserController(ser.DB, ser.Mail)
 	userapi := api.NewUserRouter(usercon, ser.Auth, ser.Log)
 
 	groupcon := controllers.NewGroupController(ser.DB)
@@ -20,8 +20,37 @@ func (ser *Server) CreateRouterInstance() {
 	transactioncon := controllers.NewGroupTransactionController(ser.DB)
 	transactionapi := api.NewGroupTransactionRouter(transactioncon, ser.Auth, ser.Log)
 
-	invitationcon := controllers.NewUserInvitationController(ser.DB)
+	invitationcon := controllers.NewUserInvitationController(ser.DB, ser.Mail)
 	invitationapi := api.NewUserInvitationRouter(invitationcon, ser.Auth, ser.Log)
 
-	ser.RegisterRoutes([]Controller{userapi, groupapi, usergroupapi, transactionapi, invitationapi})
+	expensecon := controllers.NewExpenseController(ser.DB)
+	expenseapi := api.NewExpenseRouter(expensecon, ser.Auth, ser.Log)
+
+	fxratecon := controllers.NewFxRateController(ser.DB)
+	fxrateapi := api.NewFxRateRouter(fxratecon, ser.Auth, ser.Log)
+
+	settlementcon := controllers.NewSettlementController(ser.DB)
+	settlementapi := api.NewSettlementRouter(settlementcon, ser.Auth, ser.Log)
+
+	placeholdercon := controllers.NewPlaceholderController(ser.DB)
+	placeholderapi := api.NewPlaceholderRouter(placeholdercon, ser.Auth, ser.Log)
+
+	invitelinkcon := controllers.NewGroupInviteLinkController(ser.DB)
+	invitelinkapi := api.NewGroupInviteLinkRouter(invitelinkcon, ser.Auth, ser.Log)
+
+	passwordcon := controllers.NewPasswordController(ser.DB, ser.Mail)
+	passwordapi := api.NewPasswordRouter(passwordcon, ser.Auth, ser.Log)
+
+	sessioncon := controllers.NewSessionController(ser.DB)
+	sessionapi := api.NewSessionRouter(sessioncon, ser.Auth, ser.Log)
+
+	mfacon := controllers.NewMfaController(ser.DB)
+	mfaapi := api.NewMfaRouter(mfacon, ser.Auth, ser.Log)
+
+	accountlockcon := controllers.NewAccountLockController(ser.DB)
+	accountlockapi := api.NewAccountLockRouter(accountlockcon, ser.Auth, ser.Log)
+
+	ser.RegisterRoutes([]Controller{userapi, groupapi, usergroupapi, transactionapi, invitationapi, expenseapi,
+		fxrateapi, settlementapi, placeholderapi, invitelinkapi, passwordapi, sessionapi,
+		mfaapi, accountlockapi})
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
+	"github.com/shaileshhb/equisplit/src/mail"
 	"github.com/shaileshhb/equisplit/src/models"
 	"github.com/shaileshhb/equisplit/src/security"
 	"gorm.io/gorm"
@@ -32,16 +33,19 @@ type Server struct {
 	WG     *sync.WaitGroup
 	Log    zerolog.Logger
 	Auth   security.Authentication
+	Mail   mail.Sender
 	// Config config.ConfReader
 }
 
-func NewServer(name string, db *gorm.DB, log zerolog.Logger, auth security.Authentication, wg *sync.WaitGroup) *Server {
+func NewServer(name string, db *gorm.DB, log zerolog.Logger, auth security.Authentication, mailer mail.Sender,
+	wg *sync.WaitGroup) *Server {
 	return &Server{
 		Name: name,
 		DB:   db,
 		// RDB:  rdb,
 		WG:   wg,
 		Auth: auth,
+		Mail: mailer,
 		Log:  log,
 		// Config:         conf,
 	}
@@ -64,6 +68,13 @@ func (ser *Server) InitializeRouter() {
 		})
 	})
 
+	// public keys which verify tokens, for services which accept tokens issued by equisplit.
+	app.Get("/.well-known/jwks.json", func(c *fiber.Ctx) error {
+		return c.Status(200).JSON(fiber.Map{
+			"keys": security.JWKS(),
+		})
+	})
+
 	apiV1 := app.Group("api/v1")
 
 	ser.App = app
@@ -82,8 +93,23 @@ func (ser *Server) MigrateTables() {
 	lo.Must0(ser.DB.AutoMigrate(&models.User{}))
 	lo.Must0(ser.DB.AutoMigrate(&models.Group{}))
 	lo.Must0(ser.DB.AutoMigrate(&models.UserGroup{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.Expense{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.ExpenseSplit{}))
 	lo.Must0(ser.DB.AutoMigrate(&models.GroupTransaction{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.GroupTransactionRevision{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.Settlement{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.SettlementAllocation{}))
 	lo.Must0(ser.DB.AutoMigrate(&models.UserInvitation{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.FxRate{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.MemberDeparture{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.GroupInviteLink{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.GroupInviteLinkJoin{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.PasswordResetToken{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.Session{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.RefreshToken{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.RecoveryCode{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.SecurityEvent{}))
+	lo.Must0(ser.DB.AutoMigrate(&models.AccountUnlockToken{}))
 
 	config := models.NewModuleConfig(ser.DB)
 	config.TableMigration(ser.WG)
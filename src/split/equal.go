@@ -0,0 +1,14 @@
+package split
+
+import "github.com/shaileshhb/equisplit/src/models"
+
+// equalStrategy divides the total equally between all participants.
+type equalStrategy struct{}
+
+func (equalStrategy) Split(total models.Money, splits []models.ExpenseSplit) ([]models.Money, error) {
+	weights := make([]int64, len(splits))
+	for index := range weights {
+		weights[index] = 1
+	}
+	return total.Allocate(weights)
+}
@@ -0,0 +1,33 @@
+package split
+
+import (
+	"errors"
+
+	"github.com/shaileshhb/equisplit/src/models"
+)
+
+// exactStrategy uses the amount specified for every participant as it is.
+type exactStrategy struct{}
+
+func (exactStrategy) Split(total models.Money, splits []models.ExpenseSplit) ([]models.Money, error) {
+	amounts := make([]models.Money, len(splits))
+	sum := models.NewMoney(0, total.Currency)
+
+	for index, s := range splits {
+		// amount without currency is in the currency of the expense.
+		amount := s.Amount
+		err := amount.SetDefaultCurrency(total.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		amounts[index] = models.NewMoney(amount.Minor, amount.Currency)
+		sum = sum.Add(amounts[index])
+	}
+
+	if sum.Minor != total.Minor {
+		return nil, errors.New("exact amounts must add up to the total amount")
+	}
+
+	return amounts, nil
+}
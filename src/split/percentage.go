@@ -0,0 +1,26 @@
+package split
+
+import (
+	"errors"
+
+	"github.com/shaileshhb/equisplit/src/models"
+)
+
+// percentageStrategy divides the total using the percentage specified for every participant.
+type percentageStrategy struct{}
+
+func (percentageStrategy) Split(total models.Money, splits []models.ExpenseSplit) ([]models.Money, error) {
+	weights := make([]int64, len(splits))
+
+	var sum int64
+	for index, s := range splits {
+		weights[index] = toWeight(s.Value)
+		sum += weights[index]
+	}
+
+	if sum != 100*weightScale {
+		return nil, errors.New("percentages must add up to 100")
+	}
+
+	return total.Allocate(weights)
+}
@@ -0,0 +1,23 @@
+package split
+
+import (
+	"errors"
+
+	"github.com/shaileshhb/equisplit/src/models"
+)
+
+// sharesStrategy divides the total in proportion to the shares specified for every participant.
+type sharesStrategy struct{}
+
+func (sharesStrategy) Split(total models.Money, splits []models.ExpenseSplit) ([]models.Money, error) {
+	weights := make([]int64, len(splits))
+
+	for index, s := range splits {
+		weights[index] = toWeight(s.Value)
+		if weights[index] <= 0 {
+			return nil, errors.New("shares must be greater than zero")
+		}
+	}
+
+	return total.Allocate(weights)
+}
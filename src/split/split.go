@@ -0,0 +1,84 @@
+package split
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/shaileshhb/equisplit/src/models"
+)
+
+// Strategy resolves the amount owed by every participant of an expense.
+// The returned amounts are in the same order as the splits and must add up to the total.
+type Strategy interface {
+	Split(total models.Money, splits []models.ExpenseSplit) ([]models.Money, error)
+}
+
+var (
+	mu         sync.RWMutex
+	strategies = map[models.SplitType]Strategy{
+		models.SplitEqual:      equalStrategy{},
+		models.SplitExact:      exactStrategy{},
+		models.SplitPercentage: percentageStrategy{},
+		models.SplitShares:     sharesStrategy{},
+	}
+)
+
+// weightScale is used to convert percentages and shares with upto 4 decimal places into weights.
+const weightScale = 10000
+
+// Register will add or replace the strategy used for specified split type.
+func Register(splitType models.SplitType, strategy Strategy) {
+	mu.Lock()
+	defer mu.Unlock()
+	strategies[splitType] = strategy
+}
+
+// GetStrategy will return the strategy registered for specified split type.
+func GetStrategy(splitType models.SplitType) (Strategy, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	strategy, ok := strategies[splitType]
+	if !ok {
+		return nil, errors.New("invalid split type specified")
+	}
+	return strategy, nil
+}
+
+// Apply will resolve the amount of every split of the expense using its split type.
+func Apply(expense *models.Expense) error {
+	strategy, err := GetStrategy(expense.SplitType)
+	if err != nil {
+		return err
+	}
+
+	amounts, err := strategy.Split(expense.Amount, expense.Splits)
+	if err != nil {
+		return err
+	}
+
+	if len(amounts) != len(expense.Splits) {
+		return errors.New("split amounts do not match the participants")
+	}
+
+	total := models.NewMoney(0, expense.Amount.Currency)
+	for index := range expense.Splits {
+		if !amounts[index].SameCurrency(expense.Amount) {
+			return errors.New("split amounts must be in the currency of the expense")
+		}
+		expense.Splits[index].Amount = amounts[index]
+		total = total.Add(amounts[index])
+	}
+
+	if total.Minor != expense.Amount.Minor {
+		return errors.New("split amounts do not add up to the total amount")
+	}
+
+	return nil
+}
+
+// toWeight will convert a percentage or share into an integer weight.
+func toWeight(value float64) int64 {
+	return int64(math.Round(value * weightScale))
+}
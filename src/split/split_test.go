@@ -0,0 +1,255 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/shaileshhb/equisplit/src/models"
+)
+
+// splitsOf will create splits with specified values.
+func splitsOf(values ...float64) []models.ExpenseSplit {
+	splits := make([]models.ExpenseSplit, len(values))
+	for index, value := range values {
+		splits[index].Value = value
+	}
+	return splits
+}
+
+// splitsOfAmounts will create splits with specified exact amounts in minor units.
+func splitsOfAmounts(currency string, amounts ...int64) []models.ExpenseSplit {
+	splits := make([]models.ExpenseSplit, len(amounts))
+	for index, amount := range amounts {
+		splits[index].Amount = models.NewMoney(amount, currency)
+	}
+	return splits
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		splitType models.SplitType
+		total     models.Money
+		splits    []models.ExpenseSplit
+		want      []int64
+		wantErr   bool
+	}{
+		{
+			name:      "equal without remainder",
+			splitType: models.SplitEqual,
+			total:     models.NewMoney(900, "INR"),
+			splits:    splitsOf(0, 0, 0),
+			want:      []int64{300, 300, 300},
+		},
+		{
+			name:      "equal gives remainder to first participants",
+			splitType: models.SplitEqual,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(0, 0, 0),
+			want:      []int64{334, 333, 333},
+		},
+		{
+			name:      "equal with two units of remainder",
+			splitType: models.SplitEqual,
+			total:     models.NewMoney(101, "JPY"),
+			splits:    splitsOf(0, 0, 0),
+			want:      []int64{34, 34, 33},
+		},
+		{
+			name:      "equal with less than one unit each",
+			splitType: models.SplitEqual,
+			total:     models.NewMoney(2, "INR"),
+			splits:    splitsOf(0, 0, 0),
+			want:      []int64{1, 1, 0},
+		},
+		{
+			name:      "equal of zero total",
+			splitType: models.SplitEqual,
+			total:     models.NewMoney(0, "INR"),
+			splits:    splitsOf(0, 0),
+			want:      []int64{0, 0},
+		},
+		{
+			name:      "equal without participants",
+			splitType: models.SplitEqual,
+			total:     models.NewMoney(100, "INR"),
+			splits:    splitsOf(),
+			wantErr:   true,
+		},
+		{
+			name:      "exact amounts",
+			splitType: models.SplitExact,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOfAmounts("INR", 250, 750),
+			want:      []int64{250, 750},
+		},
+		{
+			name:      "exact amounts which do not add up",
+			splitType: models.SplitExact,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOfAmounts("INR", 250, 700),
+			wantErr:   true,
+		},
+		{
+			name:      "percentage without remainder",
+			splitType: models.SplitPercentage,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(50, 30, 20),
+			want:      []int64{500, 300, 200},
+		},
+		{
+			name:      "percentage gives remainder to largest remainder",
+			splitType: models.SplitPercentage,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(33.3333, 33.3333, 33.3334),
+			want:      []int64{333, 333, 334},
+		},
+		{
+			name:      "percentage with equal remainders",
+			splitType: models.SplitPercentage,
+			total:     models.NewMoney(101, "INR"),
+			splits:    splitsOf(50, 50),
+			want:      []int64{51, 50},
+		},
+		{
+			name:      "percentages below 100",
+			splitType: models.SplitPercentage,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(50, 49.99),
+			wantErr:   true,
+		},
+		{
+			name:      "percentages above 100",
+			splitType: models.SplitPercentage,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(60, 50),
+			wantErr:   true,
+		},
+		{
+			name:      "negative percentage which adds up to 100",
+			splitType: models.SplitPercentage,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(150, -50),
+			wantErr:   true,
+		},
+		{
+			name:      "shares",
+			splitType: models.SplitShares,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(1, 2, 2),
+			want:      []int64{200, 400, 400},
+		},
+		{
+			name:      "shares with remainder",
+			splitType: models.SplitShares,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(1, 1, 1),
+			want:      []int64{334, 333, 333},
+		},
+		{
+			name:      "fractional shares",
+			splitType: models.SplitShares,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(0.5, 1.5),
+			want:      []int64{250, 750},
+		},
+		{
+			name:      "zero share",
+			splitType: models.SplitShares,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(1, 0),
+			wantErr:   true,
+		},
+		{
+			name:      "negative share",
+			splitType: models.SplitShares,
+			total:     models.NewMoney(1000, "INR"),
+			splits:    splitsOf(2, -1),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := GetStrategy(tt.splitType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			amounts, err := strategy.Split(tt.total, tt.splits)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", amounts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(amounts) != len(tt.want) {
+				t.Fatalf("got %d amounts, want %d", len(amounts), len(tt.want))
+			}
+
+			var sum int64
+			for index, amount := range amounts {
+				if amount.Minor != tt.want[index] {
+					t.Errorf("amount %d = %d, want %d", index, amount.Minor, tt.want[index])
+				}
+				if !amount.SameCurrency(tt.total) {
+					t.Errorf("amount %d is in %s, want %s", index, amount.Currency, tt.total.Currency)
+				}
+				sum += amount.Minor
+			}
+
+			if sum != tt.total.Minor {
+				t.Errorf("amounts add up to %d, want %d", sum, tt.total.Minor)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	expense := models.Expense{
+		Amount:    models.NewMoney(1000, "USD"),
+		SplitType: models.SplitEqual,
+		Splits:    splitsOf(0, 0, 0),
+	}
+
+	err := Apply(&expense)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int64{334, 333, 333}
+	for index, s := range expense.Splits {
+		if s.Amount.Minor != want[index] || s.Amount.Currency != "USD" {
+			t.Errorf("split %d = %d %s, want %d USD", index, s.Amount.Minor, s.Amount.Currency, want[index])
+		}
+	}
+}
+
+func TestApplyInvalidSplitType(t *testing.T) {
+	expense := models.Expense{
+		Amount:    models.NewMoney(1000, "INR"),
+		SplitType: models.SplitType("unknown"),
+		Splits:    splitsOf(0, 0),
+	}
+
+	err := Apply(&expense)
+	if err == nil {
+		t.Fatal("expected error for unknown split type")
+	}
+}
+
+func TestApplyExactInOtherCurrency(t *testing.T) {
+	expense := models.Expense{
+		Amount:    models.NewMoney(1000, "INR"),
+		SplitType: models.SplitExact,
+		Splits:    splitsOfAmounts("USD", 500, 500),
+	}
+
+	err := Apply(&expense)
+	if err == nil {
+		t.Fatal("expected error for split amounts in another currency")
+	}
+}